@@ -0,0 +1,53 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/cespare/saturday"
+	"github.com/cespare/saturday/proof"
+)
+
+func checkProof(args []string) {
+	fs := flag.NewFlagSet("check-proof", flag.ExitOnError)
+	lrat := fs.Bool("lrat", false, "check an LRAT proof (rather than DRAT)")
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, `Usage:
+
+  saturday check-proof [-lrat] input.cnf proof
+
+The check-proof subcommand checks that the given DRAT proof (or LRAT proof, if
+-lrat is given) shows that the DIMACS CNF problem in input.cnf is
+unsatisfiable. The text and binary proof formats are both accepted.
+
+If the proof is valid, check-proof prints VERIFIED. Otherwise, it prints an
+error and exits with a nonzero status.
+`)
+	}
+	fs.Parse(args)
+	if fs.NArg() != 2 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	cnf, err := saturday.ParseDIMACSFile(fs.Arg(0))
+	if err != nil {
+		log.Fatalln("Error reading input file as DIMACS CNF:", err)
+	}
+
+	pf, err := os.Open(fs.Arg(1))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer pf.Close()
+	check := proof.CheckDRAT
+	if *lrat {
+		check = proof.CheckLRAT
+	}
+	if err := check(cnf, pf); err != nil {
+		log.Fatalln("Proof check failed:", err)
+	}
+	fmt.Println("VERIFIED")
+}
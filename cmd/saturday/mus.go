@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/cespare/saturday"
+)
+
+func mus(args []string) {
+	fs := flag.NewFlagSet("mus", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, `Usage:
+
+  saturday mus [input.cnf]
+
+The mus subcommand finds a minimal unsatisfiable subset (MUS) of the clauses of
+an unsatisfiable DIMACS CNF problem: a subset of the clauses which is
+unsatisfiable but becomes satisfiable if any one clause is removed.
+
+It prints each clause of the MUS on its own line, preceded by its (1-based)
+position in the input file. If the problem is satisfiable, mus prints an error
+and exits with a nonzero status.
+
+If no input file is given, mus reads from standard input. As with the main
+command, the input may be compressed with gzip, bzip2, or xz.
+`)
+	}
+	fs.Parse(args)
+
+	cnf, err := readInput(fs.Arg(0))
+	if err != nil {
+		log.Fatalln("Error reading input file as DIMACS CNF:", err)
+	}
+
+	clauses, err := saturday.MUS(cnf)
+	if err != nil {
+		log.Fatalln("Cannot find MUS:", err)
+	}
+	w := bufio.NewWriter(os.Stdout)
+	for _, i := range clauses {
+		fmt.Fprintf(w, "%d:", i+1)
+		for _, v := range cnf[i] {
+			fmt.Fprintf(w, " %d", v)
+		}
+		fmt.Fprintln(w, " 0")
+	}
+	if err := w.Flush(); err != nil {
+		log.Fatal(err)
+	}
+}
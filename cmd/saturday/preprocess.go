@@ -0,0 +1,135 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/cespare/saturday"
+)
+
+func preprocess(args []string) {
+	fs := flag.NewFlagSet("preprocess", flag.ExitOnError)
+	verbose := fs.Bool("v", false, "print stats")
+	reconFile := fs.String("recon", "", "write the reconstruction data to this file (default output.cnf.recon)")
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, `Usage:
+
+  saturday preprocess [-v] [-recon file] input.cnf output.cnf
+
+The preprocess subcommand simplifies the DIMACS CNF problem in input.cnf using
+all of saturday's preprocessing techniques and writes the result, with its vars
+renumbered to be contiguous, to output.cnf. This lets saturday be used as a
+preprocessor for other solvers.
+
+It also writes a file (output.cnf.recon, unless -recon is given) holding the
+mapping from the new vars to the original ones and the other information
+needed to turn a model of the simplified problem into a model of the original
+problem. Use the postprocess subcommand to do that.
+
+If preprocessing finds that the problem is unsatisfiable, output.cnf contains
+only an empty clause. If preprocessing solves the problem, output.cnf has no
+clauses (so any assignment is a model).
+
+The -v flag prints a summary of the preprocessing stats. As with the main
+command, the input may be compressed with gzip, bzip2, or xz.
+`)
+	}
+	fs.Parse(args)
+	if fs.NArg() != 2 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	if *reconFile == "" {
+		*reconFile = fs.Arg(1) + ".recon"
+	}
+
+	cnf, err := saturday.ParseDIMACSFile(fs.Arg(0))
+	if err != nil {
+		log.Fatalln("Error reading input file as DIMACS CNF:", err)
+	}
+	simplified, recon, stats := saturday.Preprocess(cnf)
+	if *verbose {
+		printStats(os.Stderr, "", stats)
+	}
+
+	if err := writeFile(fs.Arg(1), func(w io.Writer) error {
+		return saturday.WriteDIMACS(w, simplified)
+	}); err != nil {
+		log.Fatalln("Error writing simplified problem:", err)
+	}
+	if err := writeFile(*reconFile, func(w io.Writer) error {
+		return saturday.WriteReconstruction(w, recon)
+	}); err != nil {
+		log.Fatalln("Error writing reconstruction data:", err)
+	}
+}
+
+func postprocess(args []string) {
+	fs := flag.NewFlagSet("postprocess", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, `Usage:
+
+  saturday postprocess output.cnf.recon [model.txt]
+
+The postprocess subcommand turns a model (satisfying assignment) of a problem
+simplified by the preprocess subcommand into a model of the original problem,
+using the reconstruction data written by preprocess.
+
+The model is read from model.txt (or from standard input, if no file is given)
+in the output format of the SAT competition: lines beginning with "v" list the
+literals of the assignment, terminated by a 0. The model of the original
+problem is printed in the same format.
+`)
+	}
+	fs.Parse(args)
+	if fs.NArg() < 1 || fs.NArg() > 2 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	recon, err := saturday.ParseReconstruction(f)
+	f.Close()
+	if err != nil {
+		log.Fatalln("Error reading reconstruction data:", err)
+	}
+
+	r := os.Stdin
+	if fs.NArg() == 2 {
+		f, err := os.Open(fs.Arg(1))
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		r = f
+	}
+	model, err := readModel(r)
+	if err != nil {
+		log.Fatalln("Error reading model:", err)
+	}
+
+	soln, err := recon.Extend(model)
+	if err != nil {
+		log.Fatalln("Cannot extend model:", err)
+	}
+	printCompetition(soln, saturday.Satisfiable)
+}
+
+// writeFile creates the named file and writes to it using write.
+func writeFile(name string, write func(w io.Writer) error) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -1,73 +1,275 @@
 package main
 
 import (
+	"bufio"
+	"context"
 	"flag"
 	"fmt"
 	"io"
 	"log"
 	"os"
-	"sort"
+	"os/signal"
+	"strconv"
+	"syscall"
 
 	"github.com/cespare/saturday"
 )
 
 func main() {
 	log.SetFlags(0)
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "check-proof":
+			checkProof(os.Args[2:])
+			return
+		case "mus":
+			mus(os.Args[2:])
+			return
+		case "verify":
+			verify(os.Args[2:])
+			return
+		case "preprocess":
+			preprocess(os.Args[2:])
+			return
+		case "postprocess":
+			postprocess(os.Args[2:])
+			return
+		}
+	}
+	os.Exit(solve())
+}
+
+// Exit codes for -competition mode.
+const (
+	exitSAT     = 10
+	exitUNSAT   = 20
+	exitUNKNOWN = 0
+)
+
+// solve runs the main command and returns the exit code.
+func solve() int {
 	verbose := flag.Bool("v", false, "verbose mode")
+	polarity := flag.String("polarity", "true", "initial value for decision vars (true, false, random, or jw)")
+	restart := flag.String("restart", "luby", "restart policy (luby, glucose, or none)")
+	preprocess := flag.Bool("preprocess", true, "simplify the problem (using subsumption, variable elimination, and probing) before solving it")
+	proofFile := flag.String("proof", "", "write a DRAT proof to this file")
+	proofFormat := flag.String("proof-format", "text", "DRAT proof format (text or binary)")
+	traceFile := flag.String("trace", "", "write a trace of the search to this file (- for stderr)")
+	check := flag.Bool("check", false, "verify the assignment before printing it")
+	competition := flag.Bool("competition", false, "use the SAT competition output format and exit codes")
 	flag.Usage = func() {
 		fmt.Fprint(os.Stderr, `Saturday: a toy SAT solver.
 
 Usage:
 
-  saturday [-v] [input.cnf]
+  saturday [-v] [-competition] [-check] [-polarity p] [-restart r] [-preprocess=false] [-proof file] [-trace file] [input.cnf]
+  saturday check-proof [-lrat] input.cnf proof
+  saturday mus [input.cnf]
+  saturday verify input.cnf model.txt
+  saturday preprocess [-v] [-recon file] input.cnf output.cnf
+  saturday postprocess output.cnf.recon [model.txt]
 
 Saturday reads a single problem specification in the DIMACS CNF format.
 It writes the output in the conventional way: either the first line is UNSAT,
 or else the first line is SAT and the second line gives the assignments in the
 same format as an input clause.
 
-If no input file is given, saturday reads from standard input.
+If -competition is given, saturday instead uses the output format of the SAT
+competition: the result is given by a line which is one of
+
+  s SATISFIABLE
+  s UNSATISFIABLE
+  s UNKNOWN
+
+followed, for a satisfiable problem, by the assignment on one or more lines
+beginning with "v" and ending with a 0. Stats (and progress, with -v) are
+printed to standard output on comment lines beginning with "c". The exit code
+is 10 for SAT, 20 for UNSAT, and 0 if saturday is interrupted (by SIGINT or
+SIGTERM) before it finds the answer. This lets saturday run under standard
+benchmarking tools such as runsolver and BenchExec.
+
+If -check is given, saturday checks that the assignment it found satisfies
+every clause of the input problem before printing it. (This is a check on the
+correctness of the solver itself and should never fail.)
+
+If no input file is given, saturday reads from standard input. The input may
+be compressed with gzip, bzip2, or xz; saturday detects this automatically.
+
+The -v flag controls verbose output: saturday prints progress lines while it
+solves the problem and a summary of its stats at the end.
+
+The -polarity flag selects the value that the solver first tries for a var:
+true, false, random, or jw (the value favored by the Jeroslow-Wang heuristic).
+
+The -restart flag selects the restart policy: luby (restart after a number of
+conflicts following the Luby sequence), glucose (restart when recently learned
+clauses are poor, as in the Glucose solver), or none.
+
+By default, saturday preprocesses the problem before solving it by substituting
+equivalent literals, removing subsumed clauses, strengthening clauses using
+self-subsuming resolution, removing pure literals and blocked clauses,
+eliminating vars where that makes the problem smaller (bounded variable
+elimination), and failed literal probing. Use -preprocess=false to turn this
+off.
+
+If -proof is given, saturday writes a DRAT proof to the named file. If the
+problem is unsatisfiable, a DRAT checker (such as drat-trim) can use the proof
+to verify the result. The -proof-format flag selects the text or binary DRAT
+format.
+
+If -trace is given, saturday writes a trace of the solver's search (decisions,
+propagations, conflicts, learned clauses, backjumps, and so on) to the named
+file, or to standard error if the file is "-". The trace shows exactly what the
+solver did, which can help in understanding its behavior on a problem. It is
+very large for all but the smallest problems.
 
-The -v flag controls verbose output.
+The check-proof subcommand checks a DRAT or LRAT proof of unsatisfiability, the
+verify subcommand checks a satisfying assignment in the SAT competition format,
+and the mus subcommand finds a minimal unsatisfiable subset of the clauses of
+an unsatisfiable problem. The preprocess subcommand writes out the simplified
+problem (for use with another solver) and the postprocess subcommand turns a
+model of the simplified problem into a model of the original. Run
+'saturday check-proof -h' (and so on) for details.
 `)
 	}
 	flag.Parse()
 
-	var r io.Reader = os.Stdin
-	if flag.NArg() >= 1 {
-		f, err := os.Open(flag.Arg(0))
+	var opts saturday.Options
+	switch *polarity {
+	case "true":
+		opts.Polarity = saturday.PolarityTrue
+	case "false":
+		opts.Polarity = saturday.PolarityFalse
+	case "random":
+		opts.Polarity = saturday.PolarityRandom
+	case "jw":
+		opts.Polarity = saturday.PolarityJeroslowWang
+	default:
+		log.Fatalf("Unknown -polarity %q", *polarity)
+	}
+	switch *restart {
+	case "luby":
+		opts.Restart = saturday.LubyRestarts(100)
+	case "glucose":
+		opts.Restart = saturday.GlucoseRestarts(50, 0.8)
+	case "none":
+		opts.Restart = saturday.NoRestarts()
+	default:
+		log.Fatalf("Unknown -restart %q", *restart)
+	}
+
+	opts.NoPreprocess = !*preprocess
+
+	var proofWriter *bufio.Writer
+	if *proofFile != "" {
+		switch *proofFormat {
+		case "text":
+			opts.ProofFormat = saturday.ProofText
+		case "binary":
+			opts.ProofFormat = saturday.ProofBinary
+		default:
+			log.Fatalf("Unknown -proof-format %q", *proofFormat)
+		}
+		f, err := os.Create(*proofFile)
 		if err != nil {
 			log.Fatal(err)
 		}
-		defer f.Close()
-		r = f
+		defer func() {
+			if err := f.Close(); err != nil {
+				log.Fatalln("Error writing proof:", err)
+			}
+		}()
+		proofWriter = bufio.NewWriter(f)
+		opts.Proof = proofWriter
 	}
 
-	cnf, err := saturday.ParseDIMACS(r)
+	// Progress and stats go to stderr, except in -competition mode, where
+	// they go to stdout as comment lines.
+	var statsWriter io.Writer = os.Stderr
+	var statsPrefix string
+	if *competition {
+		statsWriter = os.Stdout
+		statsPrefix = "c "
+	}
+	if *verbose {
+		opts.Progress = func(stats saturday.Stats) {
+			printProgress(statsWriter, statsPrefix, stats)
+		}
+	}
+
+	var traceWriter *bufio.Writer
+	switch *traceFile {
+	case "":
+	case "-":
+		traceWriter = bufio.NewWriter(os.Stderr)
+	default:
+		f, err := os.Create(*traceFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer func() {
+			if err := f.Close(); err != nil {
+				log.Fatalln("Error writing trace:", err)
+			}
+		}()
+		traceWriter = bufio.NewWriter(f)
+	}
+	if traceWriter != nil {
+		opts.Trace = traceWriter
+	}
+
+	cnf, err := readInput(flag.Arg(0))
 	if err != nil {
 		log.Fatalln("Error reading input file as DIMACS CNF:", err)
 	}
 
-	soln, stats, ok := saturday.Solve(cnf)
-	if *verbose {
-		var keys []string
-		var maxKeyLen int
-		for key := range stats {
-			keys = append(keys, key)
-			if len(key) > maxKeyLen {
-				maxKeyLen = len(key)
-			}
+	ctx := context.Background()
+	if *competition {
+		// Benchmarking tools send SIGTERM (or the user types ^C) when
+		// the time is up; report UNKNOWN instead of dying silently.
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithCancel(ctx)
+		defer cancel()
+		sigs := make(chan os.Signal, 1)
+		signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+		go func() {
+			<-sigs
+			cancel()
+		}()
+	}
+
+	soln, stats, result := saturday.SolveContext(ctx, cnf, &opts)
+	if proofWriter != nil {
+		if err := proofWriter.Flush(); err != nil {
+			log.Fatalln("Error writing proof:", err)
 		}
-		sort.Strings(keys)
-		for _, key := range keys {
-			fmt.Fprintf(os.Stderr, "%*s %v\n", maxKeyLen, key, stats[key])
+	}
+	if traceWriter != nil {
+		if err := traceWriter.Flush(); err != nil {
+			log.Fatalln("Error writing trace:", err)
 		}
 	}
-	if !ok {
+	if *check && result == saturday.Satisfiable {
+		if err := saturday.Verify(cnf, soln); err != nil {
+			log.Fatalln("Solver found an invalid assignment:", err)
+		}
+	}
+	if *verbose || *competition {
+		printStats(statsWriter, statsPrefix, stats)
+	}
+	if *competition {
+		return printCompetition(soln, result)
+	}
+	switch result {
+	case saturday.Satisfiable:
+		fmt.Println("SAT")
+	case saturday.Unsatisfiable:
 		fmt.Println("UNSAT")
-		return
+		return 0
+	default:
+		fmt.Println("UNKNOWN")
+		return 0
 	}
-	fmt.Println("SAT")
 	for i, v := range soln {
 		if i > 0 {
 			fmt.Print(" ")
@@ -75,4 +277,92 @@ The -v flag controls verbose output.
 		fmt.Print(v)
 	}
 	fmt.Println()
+	return 0
+}
+
+// modelLineWidth is the width at which -competition mode wraps the "v"
+// lines of the assignment.
+const modelLineWidth = 78
+
+func printCompetition(soln []int, result saturday.Result) int {
+	w := bufio.NewWriter(os.Stdout)
+	var code int
+	switch result {
+	case saturday.Satisfiable:
+		fmt.Fprintln(w, "s SATISFIABLE")
+		code = exitSAT
+	case saturday.Unsatisfiable:
+		fmt.Fprintln(w, "s UNSATISFIABLE")
+		code = exitUNSAT
+	default:
+		fmt.Fprintln(w, "s UNKNOWN")
+		code = exitUNKNOWN
+	}
+	if result == saturday.Satisfiable {
+		line := []byte("v")
+		for _, v := range append(soln, 0) {
+			n := strconv.Itoa(v)
+			if len(line)+1+len(n) > modelLineWidth {
+				w.Write(line)
+				w.WriteByte('\n')
+				line = append(line[:0], 'v')
+			}
+			line = append(line, ' ')
+			line = append(line, n...)
+		}
+		w.Write(line)
+		w.WriteByte('\n')
+	}
+	if err := w.Flush(); err != nil {
+		log.Fatalln("Error writing output:", err)
+	}
+	return code
+}
+
+func printProgress(w io.Writer, prefix string, stats saturday.Stats) {
+	fmt.Fprintf(w,
+		"%s[%6.1fs] decisions: %d  conflicts: %d  restarts: %d  learned clauses: %d (%d kept)\n",
+		prefix, stats.ElapsedTime.Seconds(), stats.Decisions, stats.Conflicts,
+		stats.Restarts, stats.LearnedClauses, stats.KeptClauses())
+}
+
+func printStats(w io.Writer, prefix string, stats saturday.Stats) {
+	for _, stat := range []struct {
+		name  string
+		value interface{}
+	}{
+		{"solved by simplification", stats.SolvedBySimplification},
+		{"eliminated vars", stats.EliminatedVars},
+		{"subsumed clauses", stats.SubsumedClauses},
+		{"strengthened literals", stats.StrengthenedLits},
+		{"substituted vars", stats.SubstitutedVars},
+		{"probed units", stats.ProbedUnits},
+		{"pure literals", stats.PureLiterals},
+		{"blocked clauses", stats.BlockedClauses},
+		{"decisions", stats.Decisions},
+		{"implications", stats.Implications},
+		{"conflicts", stats.Conflicts},
+		{"restarts", stats.Restarts},
+		{"learned clauses", stats.LearnedClauses},
+		{"minimized literals", stats.MinimizedLits},
+		{"db reductions", stats.DBReductions},
+		{"deleted clauses", stats.DeletedClauses},
+		{"kept learned clauses", stats.KeptClauses()},
+		{"elapsed time", stats.ElapsedTime},
+	} {
+		fmt.Fprintf(w, "%s%24s %v\n", prefix, stat.name, stat.value)
+	}
+}
+
+// readInput parses the named DIMACS CNF file (or standard input, if name is
+// empty), decompressing it first if necessary.
+func readInput(name string) ([][]int, error) {
+	if name != "" {
+		return saturday.ParseDIMACSFile(name)
+	}
+	r, err := saturday.Decompress(os.Stdin)
+	if err != nil {
+		return nil, err
+	}
+	return saturday.ParseDIMACS(r)
 }
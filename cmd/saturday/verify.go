@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/cespare/saturday"
+)
+
+func verify(args []string) {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, `Usage:
+
+  saturday verify input.cnf model.txt
+
+The verify subcommand checks that a model (satisfying assignment) is a solution
+to the DIMACS CNF problem in input.cnf. The model is given in the output format
+of the SAT competition (as written by saturday -competition): lines beginning
+with "v" list the literals of the assignment, terminated by a 0. Comment lines
+(beginning with "c") are ignored, as is an "s SATISFIABLE" line.
+
+If the model satisfies every clause, verify prints VERIFIED. Otherwise, it
+prints the first clause that isn't satisfied and exits with a nonzero status.
+`)
+	}
+	fs.Parse(args)
+	if fs.NArg() != 2 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	cnf, err := saturday.ParseDIMACSFile(fs.Arg(0))
+	if err != nil {
+		log.Fatalln("Error reading input file as DIMACS CNF:", err)
+	}
+
+	f, err := os.Open(fs.Arg(1))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+	model, err := readModel(f)
+	if err != nil {
+		log.Fatalln("Error reading model:", err)
+	}
+
+	if err := saturday.Verify(cnf, model); err != nil {
+		log.Fatalln("Verification failed:", err)
+	}
+	fmt.Println("VERIFIED")
+}
+
+// readModel reads an assignment from the "v" lines of SAT competition output.
+func readModel(r io.Reader) ([]int, error) {
+	var model []int
+	done := false
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<30)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "c":
+			continue
+		case "s":
+			if status := strings.Join(fields[1:], " "); status != "SATISFIABLE" {
+				return nil, fmt.Errorf("line %d: status is %s, not SATISFIABLE", lineNum, status)
+			}
+			continue
+		case "v":
+		default:
+			return nil, fmt.Errorf("line %d: unexpected line type %q", lineNum, fields[0])
+		}
+		for _, field := range fields[1:] {
+			if done {
+				return nil, fmt.Errorf("line %d: values after terminating 0", lineNum)
+			}
+			n, err := strconv.Atoi(field)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: bad literal %q", lineNum, field)
+			}
+			if n == 0 {
+				done = true
+				continue
+			}
+			model = append(model, n)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if !done {
+		return nil, errors.New("model is not terminated by 0")
+	}
+	return model, nil
+}
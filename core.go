@@ -0,0 +1,184 @@
+package saturday
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// ErrSatisfiable is returned by functions that only apply to unsatisfiable
+// problems, such as UnsatCore, when the problem is satisfiable.
+var ErrSatisfiable = errors.New("problem is satisfiable")
+
+// UnsatCore determines whether a boolean formula is unsatisfiable and, if it
+// is, finds an unsatisfiable core: a subset of the clauses that is
+// unsatisfiable on its own. The problem is given in the same form as for
+// Solve. The core is returned as a sorted list of indexes into problem.
+//
+// UnsatCore records how every clause used by the solver is derived from the
+// problem clauses and returns those that the final conflict depends on. The
+// core is not necessarily minimal (see MUS).
+//
+// If the problem is satisfiable, UnsatCore returns ErrSatisfiable.
+func UnsatCore(problem [][]int) ([]int, error) {
+	if err := checkProblem(problem); err != nil {
+		return nil, err
+	}
+	sv := &solver{trackCore: true}
+	sv.init(nil)
+	sv.load(problem)
+	if sv.solve() == Satisfiable {
+		return nil, ErrSatisfiable
+	}
+	return sv.unsatCore(), nil
+}
+
+func checkProblem(problem [][]int) error {
+	for i, cls := range problem {
+		for _, v := range cls {
+			if v == 0 {
+				return fmt.Errorf("zero literal in clause %d", i)
+			}
+		}
+	}
+	return nil
+}
+
+// derive records a new clause derived from the clauses with the IDs in ants
+// and returns its ID.
+func (sv *solver) derive(ants []int) int {
+	id := len(sv.derivs)
+	sv.derivs = append(sv.derivs, append([]int(nil), ants...))
+	return id
+}
+
+// unitID returns the ID of a unit clause giving the value of v, which must be
+// assigned at level 0.
+func (sv *solver) unitID(v int) int {
+	if id := sv.unitIDs[v]; id >= 0 {
+		return id
+	}
+	// v was implied at level 0 by its reason clause. Resolving the
+	// reason with the unit clauses for its other (false) literals gives
+	// the unit clause for v.
+	cls := &sv.clauses[sv.reasons[v]]
+	ants := []int{cls.id}
+	for _, lit := range cls.lits {
+		if int(lit>>1) != v {
+			ants = append(ants, sv.unitID(int(lit>>1)))
+		}
+	}
+	id := sv.derive(ants)
+	sv.unitIDs[v] = id
+	return id
+}
+
+// setCore sets coreIDs to the clauses involved in a conflict at level 0: the
+// conflicting clause itself and the unit clauses for its literals.
+func (sv *solver) setCore(clauseIdx int) {
+	cls := &sv.clauses[clauseIdx]
+	sv.coreIDs = append(sv.coreIDs[:0], cls.id)
+	for _, lit := range cls.lits {
+		sv.coreIDs = append(sv.coreIDs, sv.unitID(int(lit>>1)))
+	}
+}
+
+// unsatCore follows the derivations of the clauses in coreIDs back to the
+// input clauses and returns their indexes, sorted.
+func (sv *solver) unsatCore() []int {
+	seen := make([]bool, len(sv.derivs))
+	stack := append([]int(nil), sv.coreIDs...)
+	var core []int
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		if ants := sv.derivs[id]; ants != nil {
+			stack = append(stack, ants...)
+		} else {
+			core = append(core, id)
+		}
+	}
+	sort.Ints(core)
+	return core
+}
+
+// MUS finds a minimal unsatisfiable subset (MUS) of the clauses of problem: an
+// unsatisfiable core such that removing any single clause makes it
+// satisfiable. As with UnsatCore, the problem is given in the same form as for
+// Solve and the MUS is returned as a sorted list of indexes into problem.
+//
+// An unsatisfiable problem may have many MUSes (of different sizes); MUS
+// returns one of them, which is not necessarily the smallest.
+//
+// If the problem is satisfiable, MUS returns ErrSatisfiable.
+func MUS(problem [][]int) ([]int, error) {
+	core, err := UnsatCore(problem)
+	if err != nil {
+		return nil, err
+	}
+	// Shrink the core using the deletion-based algorithm: try removing
+	// each clause in turn and put it back if the rest is satisfiable.
+	//
+	// Each clause gets a selector var (a new var not in the problem) which
+	// is added to the clause negated so that assuming the selector is
+	// true enables the clause.
+	var maxVar int
+	for _, cls := range problem {
+		for _, v := range cls {
+			if abs(v) > maxVar {
+				maxVar = abs(v)
+			}
+		}
+	}
+	selector := func(i int) int { return maxVar + 1 + i }
+	s := NewSolver(nil)
+	for _, i := range core {
+		cls := make([]int, 0, len(problem[i])+1)
+		cls = append(cls, problem[i]...)
+		s.AddClause(append(cls, -selector(i))...)
+	}
+	var mus []int
+	candidates := core
+	var assumptions []int
+	for len(candidates) > 0 {
+		i := candidates[0]
+		candidates = candidates[1:]
+		assumptions = assumptions[:0]
+		for _, j := range candidates {
+			assumptions = append(assumptions, selector(j))
+		}
+		sat, err := s.SolveAssuming(assumptions)
+		if err != nil {
+			return nil, err
+		}
+		if sat {
+			// Clause i is needed. It's in every MUS of the
+			// remaining clauses, so it can be enabled permanently.
+			mus = append(mus, i)
+			s.AddClause(selector(i))
+			continue
+		}
+		s.AddClause(-selector(i))
+		// The clauses needed to prove unsatisfiability form a smaller
+		// core, so the rest can be removed too.
+		failed := make(map[int]struct{})
+		for _, v := range s.FailedAssumptions() {
+			failed[v] = struct{}{}
+		}
+		var next []int
+		for _, j := range candidates {
+			if _, ok := failed[selector(j)]; ok {
+				next = append(next, j)
+			} else {
+				s.AddClause(-selector(j))
+			}
+		}
+		candidates = next
+	}
+	sort.Ints(mus)
+	return mus, nil
+}
@@ -0,0 +1,172 @@
+package saturday
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestUnsatCore(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		problem [][]int
+		want    []int
+	}{
+		{
+			name:    "empty clause",
+			problem: [][]int{{1, 2}, {}, {3}},
+			want:    []int{1},
+		},
+		{
+			name:    "contradictory units",
+			problem: [][]int{{1}, {2, 3}, {-1}},
+			want:    []int{0, 2},
+		},
+		{
+			name:    "unit propagation",
+			problem: [][]int{{1}, {-1, 2}, {3, 4}, {-2}},
+			want:    []int{0, 1, 3},
+		},
+		{
+			name: "search",
+			problem: [][]int{
+				{1, 2, 3},
+				{1, 2},
+				{-1, 2},
+				{5, -4},
+				{1, -2},
+				{-1, -2},
+			},
+			want: []int{1, 2, 4, 5},
+		},
+		{
+			name: "search after simplification",
+			problem: [][]int{
+				{-5, 1, 2},
+				{5},
+				{-1, 2},
+				{1, -2},
+				{6, 7},
+				{-1, -2},
+			},
+			want: []int{0, 1, 2, 3, 5},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := UnsatCore(tt.problem)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Fatalf("UnsatCore (-got, +want):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestUnsatCoreFixtures(t *testing.T) {
+	for _, tt := range loadFixtures(t, false) {
+		t.Run(tt.name, func(t *testing.T) {
+			core, err := UnsatCore(tt.problem)
+			if tt.sat {
+				if err != ErrSatisfiable {
+					t.Fatalf("got err=%v; want ErrSatisfiable", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			testCoreUnsat(t, tt.problem, core)
+		})
+	}
+}
+
+// testCoreUnsat checks that core lists distinct clauses of problem (in order)
+// which are unsatisfiable.
+func testCoreUnsat(t *testing.T, problem [][]int, core []int) {
+	t.Helper()
+	var sub [][]int
+	for i, idx := range core {
+		if idx < 0 || idx >= len(problem) || (i > 0 && idx <= core[i-1]) {
+			t.Fatalf("bad core %v for problem with %d clauses", core, len(problem))
+		}
+		sub = append(sub, append([]int(nil), problem[idx]...))
+	}
+	if soln, _, ok := Solve(sub); ok {
+		t.Fatalf("core %v is satisfiable (assignment %v)", core, soln)
+	}
+}
+
+func TestUnsatCoreZeroLiteral(t *testing.T) {
+	if _, err := UnsatCore([][]int{{1, 0}}); err == nil {
+		t.Fatal("got nil error for zero literal")
+	}
+}
+
+func ExampleUnsatCore() {
+	// Problem: (x ∨ y) ∧ ¬x ∧ (z ∨ x) ∧ ¬y ∧ z
+	problem := [][]int{
+		{1, 2},
+		{-1},
+		{3, 1},
+		{-2},
+		{3},
+	}
+	core, err := UnsatCore(problem)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println("unsatisfiable core:", core)
+	// Output: unsatisfiable core: [0 1 3]
+}
+
+func TestMUS(t *testing.T) {
+	for _, tt := range loadFixtures(t, false) {
+		t.Run(tt.name, func(t *testing.T) {
+			mus, err := MUS(tt.problem)
+			if tt.sat {
+				if err != ErrSatisfiable {
+					t.Fatalf("got err=%v; want ErrSatisfiable", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			testCoreUnsat(t, tt.problem, mus)
+			// Removing any clause must make it satisfiable.
+			for i := range mus {
+				var sub [][]int
+				for j, idx := range mus {
+					if j != i {
+						sub = append(sub, append([]int(nil), tt.problem[idx]...))
+					}
+				}
+				if _, _, ok := Solve(sub); !ok {
+					t.Fatalf("MUS %v is still unsatisfiable without clause %d", mus, mus[i])
+				}
+			}
+		})
+	}
+}
+
+func ExampleMUS() {
+	// Problem: (x ∨ y) ∧ ¬x ∧ ¬y ∧ (¬x ∨ ¬y) ∧ (x ∨ ¬y)
+	problem := [][]int{
+		{1, 2},
+		{-1},
+		{-2},
+		{-1, -2},
+		{1, -2},
+	}
+	mus, err := MUS(problem)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println("minimal unsatisfiable subset:", mus)
+	// Output: minimal unsatisfiable subset: [0 1 2]
+}
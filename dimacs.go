@@ -2,11 +2,17 @@ package saturday
 
 import (
 	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
 	"errors"
 	"fmt"
 	"io"
+	"os"
 	"strconv"
 	"strings"
+
+	"github.com/ulikunitz/xz"
 )
 
 // ParseDIMACS parses text in the DIMACS CNF format.
@@ -18,100 +24,264 @@ import (
 //   * The problem line may be missing.
 //
 func ParseDIMACS(r io.Reader) ([][]int, error) {
+	var clauses [][]int
+	// Rather than allocating each clause separately, carve them out of
+	// larger chunks.
+	var chunk []int
+	err := ScanDIMACS(r, func(clause []int) error {
+		if len(clause) > cap(chunk)-len(chunk) {
+			n := 1 << 16
+			if len(clause) > n {
+				n = len(clause)
+			}
+			chunk = make([]int, 0, n)
+		}
+		start := len(chunk)
+		chunk = append(chunk, clause...)
+		clauses = append(clauses, chunk[start:len(chunk):len(chunk)])
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return clauses, nil
+}
+
+// ParseDIMACSFile parses the named file in the DIMACS CNF format (see
+// ParseDIMACS). If the file is compressed with gzip, bzip2, or xz, it is
+// decompressed transparently (see Decompress).
+func ParseDIMACSFile(name string) ([][]int, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	r, err := Decompress(f)
+	if err != nil {
+		return nil, fmt.Errorf("error decompressing %s: %s", name, err)
+	}
+	return ParseDIMACS(r)
+}
+
+// Decompress detects whether r holds gzip, bzip2, or xz compressed data (as
+// is common for benchmark problems distributed as .cnf.gz files and the like)
+// by looking at the first few bytes. If so, it returns a reader that
+// decompresses the data; otherwise, it returns a reader that yields the data
+// of r unchanged.
+func Decompress(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(6)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	switch {
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		return gzip.NewReader(br)
+	case bytes.HasPrefix(magic, []byte("BZh")):
+		return bzip2.NewReader(br), nil
+	case bytes.HasPrefix(magic, []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}):
+		return xz.NewReader(br)
+	}
+	return br, nil
+}
+
+// ScanDIMACS parses text in the DIMACS CNF format (with the same variations
+// as ParseDIMACS) and calls fn with each clause in turn. Unlike ParseDIMACS,
+// ScanDIMACS doesn't hold the whole problem in memory, so it is suitable for
+// very large inputs. For example, to add the clauses directly to a Solver:
+//
+//	err := saturday.ScanDIMACS(r, func(clause []int) error {
+//		s.AddClause(clause...)
+//		return nil
+//	})
+//
+// The clause slice passed to fn is only valid until fn returns; fn must copy
+// it to keep it any longer.
+//
+// If fn returns an error, ScanDIMACS stops and returns that error. Since the
+// input is checked as it is read, ScanDIMACS may call fn for some clauses
+// before returning an error for malformed input (including an input where
+// the number of clauses doesn't match the problem line).
+func ScanDIMACS(r io.Reader, fn func(clause []int) error) error {
+	s := dimacsScanner{r: bufio.NewReaderSize(r, 64<<10)}
 	var problem struct {
 		vars    int
 		clauses int
 	}
-	var clauses [][]int
+	numClauses := 0
 	var clause []int
-	s := bufio.NewScanner(r)
-	for s.Scan() {
-		line := s.Text()
-		if len(line) == 0 || line[0] == 'c' {
-			continue
+	for {
+		line, err := s.readLine()
+		if err != nil && err != io.EOF {
+			return err
 		}
-		// Some CNF formats attach extra data in a trailer after a line
-		// containing a single %.
-		if line == "%" {
-			break
+		eof := err == io.EOF
+		// Trim the line ending (like bufio.ScanLines).
+		if len(line) > 0 && line[len(line)-1] == '\n' {
+			line = line[:len(line)-1]
 		}
-		if line[0] == 'p' {
-			if len(clauses) > 0 {
-				return nil, errors.New("problem line appears after clauses")
+		if len(line) > 0 && line[len(line)-1] == '\r' {
+			line = line[:len(line)-1]
+		}
+		switch {
+		case len(line) == 0 || line[0] == 'c':
+		case len(line) == 1 && line[0] == '%':
+			// Some CNF formats attach extra data in a trailer
+			// after a line containing a single %.
+			eof = true
+		case line[0] == 'p':
+			if numClauses > 0 {
+				return errors.New("problem line appears after clauses")
 			}
 			if problem.vars > 0 {
-				return nil, errors.New("multiple problem lines")
+				return errors.New("multiple problem lines")
 			}
-			fields := strings.Fields(line)
+			fields := strings.Fields(string(line))
 			if len(fields) != 4 {
-				return nil, fmt.Errorf("malformed problem line %q", line)
+				return fmt.Errorf("malformed problem line %q", line)
 			}
 			if fields[0] != "p" {
-				return nil, fmt.Errorf("problem line starts with unexpected signifier %q", fields[0])
+				return fmt.Errorf("problem line starts with unexpected signifier %q", fields[0])
 			}
 			if fields[1] != "cnf" {
-				return nil, fmt.Errorf("only cnf supported; got %q", fields[1])
+				return fmt.Errorf("only cnf supported; got %q", fields[1])
 			}
 			var err error
 			problem.vars, err = strconv.Atoi(fields[2])
 			if err != nil {
-				return nil, fmt.Errorf("malformed #vars in problem line: %s", err)
+				return fmt.Errorf("malformed #vars in problem line: %s", err)
 			}
 			problem.clauses, err = strconv.Atoi(fields[3])
 			if err != nil {
-				return nil, fmt.Errorf("malformed #clauses in problem line: %s", err)
+				return fmt.Errorf("malformed #clauses in problem line: %s", err)
 			}
 			if problem.vars < 0 {
-				return nil, fmt.Errorf("invalid #vars %d", problem.vars)
+				return fmt.Errorf("invalid #vars %d", problem.vars)
 			}
 			if problem.clauses < 0 {
-				return nil, fmt.Errorf("invalid #clauses %d", problem.clauses)
-			}
-			continue
-		}
-		for _, field := range strings.Fields(line) {
-			n, err := strconv.Atoi(field)
-			if err != nil {
-				return nil, fmt.Errorf("invalid variable: %s", err)
+				return fmt.Errorf("invalid #clauses %d", problem.clauses)
 			}
-			if n == 0 {
-				clauses = append(clauses, clause)
-				clause = nil
-			} else {
+		default:
+			for {
+				tok := nextField(&line)
+				if tok == nil {
+					break
+				}
+				n, err := parseInt(tok)
+				if err != nil {
+					return fmt.Errorf("invalid variable: %s", err)
+				}
+				if n == 0 {
+					if err := fn(clause); err != nil {
+						return err
+					}
+					numClauses++
+					clause = clause[:0]
+					continue
+				}
+				if problem.vars > 0 && (n > problem.vars || -n > problem.vars) {
+					v := n
+					if v < 0 {
+						v = -v
+					}
+					return fmt.Errorf("formula contains var %d, but problem line asserts %d vars (only vars in [1, %d] expected)",
+						v, problem.vars, problem.vars)
+				}
 				clause = append(clause, n)
 			}
 		}
-	}
-	if err := s.Err(); err != nil {
-		return nil, err
+		if eof {
+			break
+		}
 	}
 	if len(clause) > 0 {
-		clauses = append(clauses, clause)
+		if err := fn(clause); err != nil {
+			return err
+		}
+		numClauses++
 	}
+	if problem.vars > 0 && numClauses != problem.clauses {
+		return fmt.Errorf("problem line specifies %d clauses, but there are %d", problem.clauses, numClauses)
+	}
+	return nil
+}
 
-	if problem.vars > 0 {
-		vars := make(map[int]struct{})
-		for _, clause := range clauses {
-			for _, v := range clause {
-				if v < 0 {
-					v = -v
-				}
-				if v > problem.vars {
-					return nil, fmt.Errorf("formula contains var %d, but problem line asserts %d vars (only vars in [1, %d] expected)",
-						v, problem.vars, problem.vars)
-				}
-				vars[v] = struct{}{}
-			}
-		}
-		// Allow some vars to be missing.
-		if len(vars) > problem.vars {
-			return nil, fmt.Errorf("problem line specifies %d vars, but there are %d", problem.vars, len(vars))
-		}
-		if len(clauses) != problem.clauses {
-			return nil, fmt.Errorf("problem line specifies %d clauses, but there are %d", problem.clauses, len(clauses))
+// A dimacsScanner reads lines of input without allocating (except to
+// accommodate lines longer than the bufio.Reader's buffer).
+type dimacsScanner struct {
+	r       *bufio.Reader
+	lineBuf []byte
+}
+
+// readLine returns the next line (including the trailing newline, if any).
+// The line is only valid until the next call to readLine. At the end of the
+// input, readLine returns the last line (which may be empty) and io.EOF.
+func (s *dimacsScanner) readLine() ([]byte, error) {
+	line, err := s.r.ReadSlice('\n')
+	if err != bufio.ErrBufferFull {
+		return line, err
+	}
+	s.lineBuf = append(s.lineBuf[:0], line...)
+	for err == bufio.ErrBufferFull {
+		line, err = s.r.ReadSlice('\n')
+		s.lineBuf = append(s.lineBuf, line...)
+	}
+	return s.lineBuf, err
+}
+
+// nextField returns the next whitespace-separated field of *line (or nil, if
+// there are none) and advances *line past it.
+func nextField(line *[]byte) []byte {
+	b := *line
+	i := 0
+	for i < len(b) && isSpace(b[i]) {
+		i++
+	}
+	if i == len(b) {
+		*line = b[i:]
+		return nil
+	}
+	j := i
+	for j < len(b) && !isSpace(b[j]) {
+		j++
+	}
+	*line = b[j:]
+	return b[i:j]
+}
+
+func isSpace(c byte) bool {
+	switch c {
+	case ' ', '\t', '\r', '\v', '\f':
+		return true
+	}
+	return false
+}
+
+// parseInt is like strconv.Atoi but avoids converting b to a string (except
+// to construct an error).
+func parseInt(b []byte) (int, error) {
+	s := b
+	neg := false
+	if len(s) > 0 && (s[0] == '-' || s[0] == '+') {
+		neg = s[0] == '-'
+		s = s[1:]
+	}
+	if len(s) == 0 || len(s) > 18 {
+		// Let strconv deal with unusual cases (and construct
+		// the error message).
+		return strconv.Atoi(string(b))
+	}
+	n := 0
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return strconv.Atoi(string(b))
 		}
+		n = n*10 + int(c-'0')
 	}
-	return clauses, nil
+	if neg {
+		n = -n
+	}
+	return n, nil
 }
 
 // WriteDIMACS writes out the given problem in the DIMACS CNF format to w.
@@ -1,7 +1,9 @@
 package saturday
 
 import (
+	"errors"
 	"fmt"
+	"io/ioutil"
 	"strings"
 	"testing"
 
@@ -143,3 +145,162 @@ x y z
 		t.Fatalf("ParseDIMACS (-got, +want):\n%s", diff)
 	}
 }
+
+func TestParseDIMACSErrors(t *testing.T) {
+	for _, tt := range []struct {
+		text string
+		want string // substring of error
+	}{
+		{"p cnf 2 1\n1 3 0\n", "formula contains var 3"},
+		{"p cnf 2 1\n-3 1 0\n", "formula contains var 3"},
+		{"p cnf 2 2\n1 2 0\n", "specifies 2 clauses, but there are 1"},
+		{"1 x 0\n", "invalid variable"},
+		{"1 99999999999999999999 0\n", "invalid variable"},
+		{"1 0\np cnf 1 1\n", "problem line appears after clauses"},
+		{"p cnf 1 1\np cnf 1 1\n1 0\n", "multiple problem lines"},
+		{"p dnf 1 1\n1 0\n", "only cnf supported"},
+		{"p cnf 1\n1 0\n", "malformed problem line"},
+	} {
+		_, err := ParseDIMACS(strings.NewReader(tt.text))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ParseDIMACS(%q): got err=%v; want error containing %q", tt.text, err, tt.want)
+		}
+	}
+}
+
+func TestScanDIMACS(t *testing.T) {
+	for _, tt := range loadFixtures(t, false) {
+		// Write out the clauses with irregular spacing and line
+		// breaks.
+		var b strings.Builder
+		for i, cls := range tt.problem {
+			fmt.Fprintf(&b, "c clause %d\n", i)
+			for j, v := range cls {
+				sep := " "
+				switch j % 3 {
+				case 1:
+					sep = " \t "
+				case 2:
+					sep = "\n"
+				}
+				fmt.Fprintf(&b, "%d%s", v, sep)
+			}
+			b.WriteString("0\n")
+		}
+		var got [][]int
+		err := ScanDIMACS(strings.NewReader(b.String()), func(clause []int) error {
+			got = append(got, append([]int(nil), clause...))
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("%s: %s", tt.name, err)
+		}
+		if diff := cmp.Diff(got, tt.problem, cmpopts.EquateEmpty()); diff != "" {
+			t.Fatalf("%s: ScanDIMACS (-got, +want):\n%s", tt.name, diff)
+		}
+	}
+}
+
+func TestScanDIMACSLongLine(t *testing.T) {
+	// A clause that's much longer than the read buffer, with CRLF line
+	// endings.
+	const n = 100000
+	var b strings.Builder
+	fmt.Fprintf(&b, "c long line\r\np cnf %d 2\r\n", n)
+	want := [][]int{make([]int, n), {-1}}
+	for i := range want[0] {
+		want[0][i] = i + 1
+		fmt.Fprintf(&b, "%d ", i+1)
+	}
+	b.WriteString("0\r\n-1 0\r\n")
+	got, err := ParseDIMACS(strings.NewReader(b.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Fatalf("ParseDIMACS (-got, +want):\n%s", diff)
+	}
+}
+
+func TestScanDIMACSCallbackError(t *testing.T) {
+	errStop := errors.New("stop")
+	var calls int
+	err := ScanDIMACS(strings.NewReader("1 2 0\n3 0\n4 0\n"), func(clause []int) error {
+		calls++
+		if calls == 2 {
+			return errStop
+		}
+		return nil
+	})
+	if err != errStop {
+		t.Fatalf("got err=%v; want %v", err, errStop)
+	}
+	if calls != 2 {
+		t.Fatalf("got %d calls; want 2", calls)
+	}
+}
+
+func TestParseDIMACSFile(t *testing.T) {
+	want, err := ParseDIMACSFile("testdata/flat30-1.sat.cnf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(want) == 0 {
+		t.Fatal("ParseDIMACSFile returned no clauses")
+	}
+	for _, ext := range []string{"gz", "bz2", "xz"} {
+		t.Run(ext, func(t *testing.T) {
+			got, err := ParseDIMACSFile("testdata/compressed/flat30-1.sat.cnf." + ext)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(got, want); diff != "" {
+				t.Fatalf("ParseDIMACSFile (-got, +want):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDecompressPlain(t *testing.T) {
+	for _, text := range []string{"", "p", "p cnf 1 1\n1 0\n"} {
+		r, err := Decompress(strings.NewReader(text))
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := ioutil.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != text {
+			t.Errorf("Decompress(%q): got %q", text, b)
+		}
+	}
+}
+
+func ExampleScanDIMACS() {
+	const cnf = `
+c Example
+p cnf 3 3
+-1 2 0
+-2 3 0
+1 -3 2 0
+`
+	// Feed the clauses directly into a Solver without building the
+	// whole problem in memory.
+	s := NewSolver(nil)
+	err := ScanDIMACS(strings.NewReader(cnf), func(clause []int) error {
+		s.AddClause(clause...)
+		return nil
+	})
+	if err != nil {
+		fmt.Println("bad input:", err)
+		return
+	}
+	sat, err := s.Solve()
+	if err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+	fmt.Println(sat, s.Model())
+	// Output: true [1 2 3]
+}
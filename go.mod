@@ -2,4 +2,7 @@ module github.com/cespare/saturday
 
 go 1.15
 
-require github.com/google/go-cmp v0.5.4
+require (
+	github.com/google/go-cmp v0.5.4
+	github.com/ulikunitz/xz v0.5.15
+)
@@ -0,0 +1,105 @@
+package saturday
+
+// varHeap is a binary max-heap of vars ordered by activity. It is used to
+// quickly find the most active unassigned var when making a decision.
+type varHeap struct {
+	activity []float64 // activity of each var (shared with the solver)
+	heap     []int
+	indices  []int // index of each var in heap (or -1)
+}
+
+func newVarHeap(activity []float64) *varHeap {
+	h := &varHeap{
+		activity: activity,
+		heap:     make([]int, len(activity)),
+		indices:  make([]int, len(activity)),
+	}
+	for v := range h.heap {
+		h.heap[v] = v
+		h.indices[v] = v
+	}
+	// All the activities start out equal, so this is already a heap.
+	return h
+}
+
+// grow adds a new var to the heap. The var is numbered len(activity)-1; the
+// given activity slice (which may have been reallocated to make room for the
+// new var) replaces the old one.
+func (h *varHeap) grow(activity []float64) {
+	h.activity = activity
+	h.indices = append(h.indices, -1)
+	h.push(len(h.indices) - 1)
+}
+
+func (h *varHeap) len() int { return len(h.heap) }
+
+func (h *varHeap) contains(v int) bool { return h.indices[v] >= 0 }
+
+func (h *varHeap) push(v int) {
+	if h.contains(v) {
+		panic("var is already in heap")
+	}
+	h.indices[v] = len(h.heap)
+	h.heap = append(h.heap, v)
+	h.up(len(h.heap) - 1)
+}
+
+// pop removes and returns the var with the highest activity.
+func (h *varHeap) pop() int {
+	v := h.heap[0]
+	last := h.heap[len(h.heap)-1]
+	h.heap = h.heap[:len(h.heap)-1]
+	h.indices[v] = -1
+	if len(h.heap) > 0 {
+		h.heap[0] = last
+		h.indices[last] = 0
+		h.down(0)
+	}
+	return v
+}
+
+// increased restores the heap ordering after the activity of v has gone up.
+func (h *varHeap) increased(v int) {
+	if i := h.indices[v]; i >= 0 {
+		h.up(i)
+	}
+}
+
+func (h *varHeap) up(i int) {
+	v := h.heap[i]
+	for i > 0 {
+		parent := (i - 1) / 2
+		pv := h.heap[parent]
+		if h.activity[pv] >= h.activity[v] {
+			break
+		}
+		h.heap[i] = pv
+		h.indices[pv] = i
+		i = parent
+	}
+	h.heap[i] = v
+	h.indices[v] = i
+}
+
+func (h *varHeap) down(i int) {
+	v := h.heap[i]
+	for {
+		child := 2*i + 1
+		if child >= len(h.heap) {
+			break
+		}
+		if right := child + 1; right < len(h.heap) &&
+			h.activity[h.heap[right]] > h.activity[h.heap[child]] {
+			child = right
+		}
+		cv := h.heap[child]
+		if h.activity[cv] <= h.activity[v] {
+			break
+		}
+		h.heap[i] = cv
+		h.indices[cv] = i
+		i = child
+	}
+	h.heap[i] = v
+	h.indices[v] = i
+}
@@ -0,0 +1,37 @@
+package saturday
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestVarHeap(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	activity := make([]float64, 100)
+	h := newVarHeap(activity)
+	for i := 0; i < 500; i++ {
+		v := rng.Intn(len(activity))
+		activity[v] += rng.Float64()
+		h.increased(v)
+	}
+	// Pop half the vars and put a few of them back.
+	var popped []int
+	for i := 0; i < 50; i++ {
+		popped = append(popped, h.pop())
+	}
+	for _, v := range popped[:10] {
+		h.push(v)
+	}
+	prev := -1
+	for h.len() > 0 {
+		v := h.pop()
+		if prev >= 0 && activity[v] > activity[prev] {
+			t.Fatalf("popped var %d (activity %g) after var %d (activity %g)",
+				v, activity[v], prev, activity[prev])
+		}
+		if h.contains(v) {
+			t.Fatalf("heap still contains popped var %d", v)
+		}
+		prev = v
+	}
+}
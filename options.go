@@ -0,0 +1,118 @@
+package saturday
+
+import (
+	"io"
+	"time"
+)
+
+// Options configure the solver. A nil *Options is equivalent to the zero
+// Options, which gives the default configuration.
+type Options struct {
+	// Polarity controls the value that the solver first tries for a
+	// decision var. Once a var has been assigned, the solver remembers its
+	// last value and reuses it for later decisions (phase saving), so
+	// Polarity only applies to vars that haven't been assigned yet.
+	Polarity Polarity
+
+	// Seed seeds the random number generator used by PolarityRandom.
+	Seed int64
+
+	// Restart is the policy that decides when the solver restarts.
+	// If Restart is nil, the solver uses LubyRestarts(100).
+	Restart RestartPolicy
+
+	// If Proof is non-nil, the solver writes a DRAT proof to it: every
+	// clause it learns or deletes, ending with the empty clause if the
+	// problem is unsatisfiable. A proof checker such as drat-trim can use
+	// this to verify an unsatisfiability result. The literals in the proof
+	// use the same variables as the input.
+	Proof io.Writer
+	// ProofFormat is the format of the proof written to Proof.
+	ProofFormat ProofFormat
+
+	// By default, Solve, SolveWithOptions, and SolveContext preprocess
+	// the problem before searching for a solution: they replace
+	// equivalent literals by a single representative, remove subsumed
+	// clauses, strengthen clauses using self-subsuming resolution, remove
+	// pure literals and blocked clauses, use bounded variable elimination
+	// to remove vars where that makes the problem smaller, and find
+	// literals that must be false using failed literal probing. This
+	// doesn't change the result, but it can make the search much faster.
+	// The solution is extended to include values for the removed vars.
+	//
+	// If NoPreprocess is set, they skip preprocessing. (The initial
+	// simplification using unit clauses is always done.) NoPreprocess has
+	// no effect on a Solver, which never preprocesses its clauses since
+	// later clauses may mention the vars that preprocessing would remove.
+	NoPreprocess bool
+
+	// MaxConflicts and MaxDecisions, if positive, limit the number of
+	// conflicts and decisions that the solver may make before giving up.
+	// For a Solver, the limits apply to each call to Solve separately.
+	MaxConflicts int64
+	MaxDecisions int64
+
+	// If Trace is non-nil, the solver writes a trace of its search to it,
+	// one event per line. Each line consists of the event name followed by
+	// space-separated key=value pairs, for example:
+	//
+	//   decide lit=-1 level=1
+	//   propagate lit=2 level=1 reason=[2,1]
+	//   conflict level=1 clause=[-2,1]
+	//   learn clause=[1] lbd=1
+	//   backjump from=1 to=0
+	//   propagate lit=1 level=0 reason=[1]
+	//
+	// The events are decide, propagate, conflict, learn, backjump,
+	// restart, reduce-db, and result (along with simplify-assign,
+	// simplify-unsat, substitute, pure, blocked, eliminate, probe, and
+	// probe-unit for the initial simplification and preprocessing).
+	// Literals use the same variables as the input. The set of events and
+	// their format may change in the future.
+	//
+	// Tracing produces a great deal of output and slows the solver down
+	// considerably; it is intended for debugging.
+	Trace io.Writer
+
+	// If Progress is non-nil, the solver calls it periodically during the
+	// search with a snapshot of the stats. The calls are made about once
+	// every ProgressInterval (or once a second, if ProgressInterval is
+	// zero), though they may be further apart if the search goes a long
+	// time between conflicts.
+	Progress         func(Stats)
+	ProgressInterval time.Duration
+}
+
+// A Polarity is a strategy for picking the value of a decision var.
+type Polarity uint8
+
+const (
+	// PolarityTrue tries true first.
+	PolarityTrue Polarity = iota
+	// PolarityFalse tries false first. This is often better for problems
+	// where most vars are false in a solution (such as many scheduling and
+	// configuration encodings).
+	PolarityFalse
+	// PolarityRandom picks a value at random.
+	PolarityRandom
+	// PolarityJeroslowWang picks the value that satisfies more (and
+	// shorter) clauses according to the Jeroslow-Wang score: each clause
+	// containing a literal contributes 2^-n to the literal's score, where n
+	// is the length of the clause.
+	PolarityJeroslowWang
+)
+
+func (p Polarity) String() string {
+	switch p {
+	case PolarityTrue:
+		return "true"
+	case PolarityFalse:
+		return "false"
+	case PolarityRandom:
+		return "random"
+	case PolarityJeroslowWang:
+		return "jeroslow-wang"
+	default:
+		return "unknown"
+	}
+}
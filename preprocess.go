@@ -0,0 +1,892 @@
+package saturday
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// A preprocessor simplifies the problem left over from simplify (that is,
+// sv.simplified) before the solver builds its clause database, using
+// techniques that are too expensive to apply during the search.
+//
+// The preprocessor's literals use the same encoding as the solver's, but the
+// vars are the indexes into sv.sourceVars rather than internal vars. Vars
+// that are assigned during preprocessing are recorded directly in
+// sv.sourceVars, just as simplify does.
+//
+// Some preprocessing techniques (such as variable elimination and blocked
+// clause elimination) remove clauses which are not implied by the remaining
+// ones. That doesn't change whether
+// the problem is satisfiable, but a solution to the smaller problem may not
+// satisfy the removed clauses. Each removed clause is saved in sv.elimStack
+// along with a witness literal so that extendSolution can fix up the
+// solution afterwards.
+type preprocessor struct {
+	sv      *solver
+	clauses []pclause
+	// occurs lists the indexes of the clauses containing each literal.
+	// Removed clauses are dropped lazily (see occurrences).
+	occurs [][]int
+	units  []literal // assigned literals that haven't been propagated yet
+	unsat  bool
+
+	// subsumeQueue holds the clauses that are new or have been
+	// strengthened since they were last checked for subsumption.
+	subsumeQueue []int
+
+	// touched marks the vars that occur in clauses which have been added
+	// or removed since the vars were last considered for elimination.
+	touched     []bool
+	touchedVars []int
+
+	marks     []bool // scratch space (one for each literal)
+	resolvent []literal
+	occBuf    []int
+}
+
+type pclause struct {
+	lits    []literal
+	sig     uint64 // see clauseSig
+	removed bool
+	queued  bool // in subsumeQueue
+}
+
+// clauseSig computes a signature for a clause: a bit set with one bit for each
+// var (modulo 64). If clause c subsumes clause d (or strengthens d through
+// self-subsuming resolution), sig(c) is a subset of sig(d), so this is a cheap
+// way to rule out most pairs.
+func clauseSig(lits []literal) uint64 {
+	var sig uint64
+	for _, lit := range lits {
+		sig |= 1 << (lit >> 1 & 63)
+	}
+	return sig
+}
+
+// An elimClause is a clause that was removed during preprocessing. If a
+// solution doesn't satisfy lits, it can be repaired by making the witness
+// literal (which is one of lits) true.
+type elimClause struct {
+	witness literal
+	lits    []literal
+}
+
+const (
+	// Variable elimination only considers vars for which one of the
+	// literals occurs in at most elimOccurLimit clauses and it gives up if
+	// any resolvent would have more than elimResolventLimit literals. (The
+	// limits are similar to those used by MiniSat.)
+	elimOccurLimit     = 10
+	elimResolventLimit = 20
+
+	// Blocked clause elimination doesn't check whether a clause is
+	// blocked on a literal l if ¬l occurs in more than blockedOccurLimit
+	// clauses.
+	blockedOccurLimit = 100
+)
+
+// preprocess runs the preprocessing passes on sv.simplified, which must not
+// be trivially satisfiable or unsatisfiable. As with simplify, the result is
+// stored in sv.simplified, sv.sourceVars, and sv.simpleSat.
+func (sv *solver) preprocess() {
+	p := newPreprocessor(sv)
+	p.substituteEquivalences()
+	p.subsume()
+	p.eliminatePureLiterals()
+	p.eliminateBlocked()
+	p.eliminateVars()
+	p.finish()
+}
+
+func newPreprocessor(sv *solver) *preprocessor {
+	p := &preprocessor{
+		sv:      sv,
+		occurs:  make([][]int, 2*len(sv.sourceVars)),
+		touched: make([]bool, len(sv.sourceVars)),
+		marks:   make([]bool, 2*len(sv.sourceVars)),
+	}
+	vars := make(map[int]int, len(sv.sourceVars))
+	for i, v := range sv.sourceVars {
+		vars[v.v] = i
+	}
+	var lits []literal
+	for _, cls := range sv.simplified {
+		lits = lits[:0]
+		for _, v := range cls {
+			lit := literal(vars[abs(v)]) << 1
+			if v < 0 {
+				lit |= 1
+			}
+			lits = append(lits, lit)
+		}
+		p.addClause(lits)
+	}
+	p.propagate()
+	return p
+}
+
+// finish stores the preprocessed problem in sv.
+func (p *preprocessor) finish() {
+	sv := p.sv
+	if p.unsat {
+		sv.simpleSat = assnFalse
+		return
+	}
+	sv.simplified = sv.simplified[:0]
+	for _, cls := range p.clauses {
+		if !cls.removed {
+			sv.simplified = append(sv.simplified, sv.sourceLits(cls.lits))
+		}
+	}
+	if len(sv.simplified) == 0 {
+		sv.simpleSat = assnTrue
+		// Pick an arbitrary assignment for the remaining vars.
+		for i, v := range sv.sourceVars {
+			if v.assn == unassigned && !v.eliminated {
+				sv.sourceVars[i].assn = assnTrue
+			}
+		}
+	}
+}
+
+// sourceLits converts preprocessor literals to source literals.
+func (sv *solver) sourceLits(lits []literal) []int {
+	s := make([]int, len(lits))
+	for i, lit := range lits {
+		s[i] = sv.sourceVars[lit>>1].v
+		if lit&1 == 1 {
+			s[i] = -s[i]
+		}
+	}
+	return s
+}
+
+func (sv *solver) sourceClauseString(lits []literal) string {
+	return strings.Replace(fmt.Sprint(sv.sourceLits(lits)), " ", ",", -1)
+}
+
+func (p *preprocessor) value(lit literal) assnVal {
+	assn := p.sv.sourceVars[lit>>1].assn
+	if assn != unassigned && lit&1 == 1 {
+		assn = assn.inv()
+	}
+	return assn
+}
+
+// addClause adds a copy of lits (which must not contain duplicates) to the
+// clause database, dropping false literals. Tautologies are skipped and unit
+// clauses are assigned (and queued for propagation) instead.
+func (p *preprocessor) addClause(lits []literal) {
+	cls := make([]literal, 0, len(lits))
+	for _, lit := range lits {
+		switch p.value(lit) {
+		case assnTrue:
+			return
+		case unassigned:
+			cls = append(cls, lit)
+		}
+	}
+	tautology := false
+	for _, lit := range cls {
+		p.marks[lit] = true
+		if p.marks[lit^1] {
+			tautology = true
+		}
+	}
+	for _, lit := range cls {
+		p.marks[lit] = false
+	}
+	if tautology {
+		return
+	}
+	switch len(cls) {
+	case 0:
+		p.setUnsat("empty-clause")
+		return
+	case 1:
+		p.assign(cls[0])
+		return
+	}
+	i := len(p.clauses)
+	p.clauses = append(p.clauses, pclause{
+		lits:   cls,
+		sig:    clauseSig(cls),
+		queued: true,
+	})
+	p.subsumeQueue = append(p.subsumeQueue, i)
+	for _, lit := range cls {
+		p.occurs[lit] = append(p.occurs[lit], i)
+		p.touch(int(lit >> 1))
+	}
+}
+
+// removeClause removes the clause at index i. It stays in the occurrence
+// lists until occurrences cleans them up.
+func (p *preprocessor) removeClause(i int) {
+	cls := &p.clauses[i]
+	cls.removed = true
+	for _, lit := range cls.lits {
+		p.touch(int(lit >> 1))
+	}
+}
+
+func (p *preprocessor) touch(v int) {
+	if !p.touched[v] {
+		p.touched[v] = true
+		p.touchedVars = append(p.touchedVars, v)
+	}
+}
+
+// occurrences returns the indexes of the clauses that contain lit.
+func (p *preprocessor) occurrences(lit literal) []int {
+	occ := p.occurs[lit][:0]
+	for _, i := range p.occurs[lit] {
+		if !p.clauses[i].removed {
+			occ = append(occ, i)
+		}
+	}
+	p.occurs[lit] = occ
+	return occ
+}
+
+func (p *preprocessor) setUnsat(reason string) {
+	if p.unsat {
+		return
+	}
+	if p.sv.trace != nil {
+		p.sv.tracef("simplify-unsat reason=%s", reason)
+	}
+	p.unsat = true
+}
+
+// assign makes lit true and queues it for propagation.
+func (p *preprocessor) assign(lit literal) {
+	switch p.value(lit) {
+	case assnTrue:
+		return
+	case assnFalse:
+		p.setUnsat("contradiction")
+		return
+	}
+	sv := p.sv
+	assn := lit.assn()
+	if sv.trace != nil {
+		sv.tracef("simplify-assign var=%d value=%s", sv.sourceVars[lit>>1].v, assn)
+	}
+	sv.sourceVars[lit>>1].assn = assn
+	p.units = append(p.units, lit)
+}
+
+// propagate removes the clauses satisfied by the queued units and removes the
+// false literals from the other clauses (which may produce more units).
+func (p *preprocessor) propagate() {
+	for len(p.units) > 0 && !p.unsat {
+		lit := p.units[len(p.units)-1]
+		p.units = p.units[:len(p.units)-1]
+		for _, i := range p.occurrences(lit) {
+			p.removeClause(i)
+		}
+		for _, i := range p.occurrences(lit ^ 1) {
+			cls := &p.clauses[i]
+			for j, q := range cls.lits {
+				if q == lit^1 {
+					cls.lits = append(cls.lits[:j], cls.lits[j+1:]...)
+					break
+				}
+			}
+			if len(cls.lits) == 1 {
+				p.removeClause(i)
+				p.assign(cls.lits[0])
+			}
+		}
+		p.occurs[lit] = nil
+		p.occurs[lit^1] = nil
+	}
+}
+
+// substituteEquivalences finds literals that are equivalent because they
+// imply each other through chains of binary clauses and replaces each set of
+// equivalent literals by a single representative throughout the problem.
+//
+// The equivalences are the strongly connected components of the binary
+// implication graph, which has an edge from ¬a to b and from ¬b to a for each
+// binary clause a ∨ b. They are found using Tarjan's algorithm.
+func (p *preprocessor) substituteEquivalences() {
+	if p.unsat {
+		return
+	}
+	sv := p.sv
+	// The graph is symmetric: if the literals in some component are
+	// equivalent, so are their negations. Using the literal with the
+	// lowest var as the representative of each component means that the
+	// representative of ¬l is always the negation of the representative
+	// of l.
+	reps := make([]literal, len(p.occurs))
+	for lit := range reps {
+		reps[lit] = literal(lit)
+	}
+	index := make([]int, len(p.occurs)) // visit order plus 1 (or 0 if unvisited)
+	low := make([]int, len(p.occurs))
+	onStack := make([]bool, len(p.occurs))
+	var stack []literal
+	type frame struct {
+		lit literal
+		i   int // next index into the occurrences of ¬lit
+	}
+	var frames []frame
+	n := 0
+	visit := func(lit literal) {
+		n++
+		index[lit] = n
+		low[lit] = n
+		stack = append(stack, lit)
+		onStack[lit] = true
+		frames = append(frames, frame{lit: lit})
+	}
+	for root := range p.occurs {
+		if index[root] > 0 {
+			continue
+		}
+		visit(literal(root))
+		for len(frames) > 0 {
+			f := &frames[len(frames)-1]
+			lit := f.lit
+			occ := p.occurs[lit^1]
+			descended := false
+			for f.i < len(occ) {
+				cls := &p.clauses[occ[f.i]]
+				f.i++
+				if cls.removed || len(cls.lits) != 2 {
+					continue
+				}
+				next := cls.lits[0]
+				if next == lit^1 {
+					next = cls.lits[1]
+				}
+				if index[next] == 0 {
+					visit(next)
+					descended = true
+					break
+				}
+				if onStack[next] && index[next] < low[lit] {
+					low[lit] = index[next]
+				}
+			}
+			if descended {
+				continue
+			}
+			frames = frames[:len(frames)-1]
+			if len(frames) > 0 {
+				if parent := frames[len(frames)-1].lit; low[lit] < low[parent] {
+					low[parent] = low[lit]
+				}
+			}
+			if low[lit] != index[lit] {
+				continue
+			}
+			// lit is the root of a component.
+			i := len(stack) - 1
+			for stack[i] != lit {
+				i--
+			}
+			comp := stack[i:]
+			stack = stack[:i]
+			rep := comp[0]
+			for _, q := range comp {
+				onStack[q] = false
+				p.marks[q] = true
+				if q>>1 < rep>>1 {
+					rep = q
+				}
+			}
+			for _, q := range comp {
+				if p.marks[q^1] && !p.unsat {
+					// q and ¬q are equivalent. To make
+					// this easy to check, give the proof
+					// the consequence ¬q first.
+					if sv.proof != nil {
+						sv.proof.add(sv.sourceLits([]literal{q ^ 1}))
+					}
+					p.setUnsat("contradiction")
+				}
+			}
+			for _, q := range comp {
+				p.marks[q] = false
+				reps[q] = rep
+			}
+		}
+		if p.unsat {
+			return
+		}
+	}
+
+	substituted := false
+	for v := range sv.sourceVars {
+		lit := literal(v) << 1
+		rep := reps[lit]
+		if rep == lit {
+			continue
+		}
+		substituted = true
+		if sv.trace != nil {
+			sv.tracef("substitute var=%d lit=%d", sv.sourceVars[v].v, sv.sourceLits([]literal{rep})[0])
+		}
+		sv.sourceVars[v].eliminated = true
+		sv.numSubstituted++
+		// The removed var has the same value as its representative.
+		sv.elimStack = append(sv.elimStack,
+			elimClause{witness: lit, lits: []literal{lit, rep ^ 1}},
+			elimClause{witness: lit ^ 1, lits: []literal{lit ^ 1, rep}},
+		)
+	}
+	if !substituted {
+		return
+	}
+	var lits []literal
+	for i, n := 0, len(p.clauses); i < n && !p.unsat; i++ {
+		cls := &p.clauses[i]
+		if cls.removed {
+			continue
+		}
+		changed, tautology := false, false
+		lits = lits[:0]
+		for _, lit := range cls.lits {
+			rep := reps[lit]
+			if rep != lit {
+				changed = true
+			}
+			if p.marks[rep^1] {
+				tautology = true
+			}
+			if !p.marks[rep] {
+				p.marks[rep] = true
+				lits = append(lits, rep)
+			}
+		}
+		for _, lit := range lits {
+			p.marks[lit] = false
+		}
+		if !changed {
+			continue
+		}
+		p.removeClause(i)
+		if tautology {
+			continue
+		}
+		if sv.proof != nil {
+			sv.proof.add(sv.sourceLits(lits))
+		}
+		p.addClause(lits)
+	}
+	for v := range sv.sourceVars {
+		if lit := literal(v) << 1; reps[lit] != lit {
+			p.occurs[lit] = nil
+			p.occurs[lit^1] = nil
+		}
+	}
+	p.propagate()
+}
+
+// eliminateVars carries out bounded variable elimination as in the SatELite
+// preprocessor: a var v is eliminated by replacing all the clauses that
+// contain v or ¬v by their resolvents on v, so long as that doesn't increase
+// the number of clauses.
+func (p *preprocessor) eliminateVars() {
+	var vars []int
+	for v := range p.sv.sourceVars {
+		vars = append(vars, v)
+	}
+	for len(vars) > 0 && !p.unsat {
+		// Get rid of any resolvents that are subsumed by others (or
+		// that subsume existing clauses).
+		p.subsume()
+		for _, v := range p.touchedVars {
+			p.touched[v] = false
+		}
+		p.touchedVars = p.touchedVars[:0]
+		// Try the cheapest vars first.
+		cost := func(v int) int {
+			return len(p.occurs[v<<1]) * len(p.occurs[v<<1|1])
+		}
+		sort.Slice(vars, func(i, j int) bool {
+			return cost(vars[i]) < cost(vars[j])
+		})
+		for _, v := range vars {
+			if p.unsat {
+				return
+			}
+			p.eliminate(v)
+		}
+		// Vars that didn't qualify may now that their clauses
+		// have changed.
+		vars = append(vars[:0], p.touchedVars...)
+	}
+}
+
+// subsume removes the clauses that are subsumed by the clauses in
+// p.subsumeQueue (that is, clauses that contain all of the literals of a
+// queued clause) and strengthens clauses using self-subsuming resolution: if
+// clause d contains all the literals of a queued clause c except for one
+// literal l which occurs negated in d, then the resolvent of c and d on l is
+// d without ¬l, which subsumes d, so ¬l can be removed from d.
+//
+// This is the backward subsumption check used by SatELite and MiniSat.
+func (p *preprocessor) subsume() {
+	for len(p.subsumeQueue) > 0 && !p.unsat {
+		p.propagate()
+		i := p.subsumeQueue[len(p.subsumeQueue)-1]
+		p.subsumeQueue = p.subsumeQueue[:len(p.subsumeQueue)-1]
+		c := &p.clauses[i]
+		c.queued = false
+		if c.removed {
+			continue
+		}
+		// Any clause that c subsumes or strengthens contains every
+		// var of c, so we only need to look at the clauses containing
+		// the var with the fewest occurrences.
+		best := c.lits[0]
+		for _, lit := range c.lits[1:] {
+			if len(p.occurs[lit])+len(p.occurs[lit^1]) < len(p.occurs[best])+len(p.occurs[best^1]) {
+				best = lit
+			}
+		}
+		for _, lit := range []literal{best, best ^ 1} {
+			// Copy the occurrences since strengthening may change
+			// them.
+			p.occBuf = append(p.occBuf[:0], p.occurrences(lit)...)
+			for _, j := range p.occBuf {
+				d := &p.clauses[j]
+				if j == i || d.removed || len(d.lits) < len(c.lits) || c.sig&^d.sig != 0 {
+					continue
+				}
+				l, ok := p.subsumes(c.lits, d.lits)
+				switch {
+				case !ok:
+				case l == litNone:
+					p.removeClause(j)
+					p.sv.numSubsumed++
+				default:
+					p.strengthen(j, l^1)
+				}
+			}
+		}
+	}
+	p.propagate()
+}
+
+// subsumes reports whether clause c subsumes clause d, in which case it
+// returns litNone, or else whether d can be strengthened using c by
+// self-subsuming resolution, in which case it returns the literal l of c such
+// that ¬l can be removed from d.
+func (p *preprocessor) subsumes(c, d []literal) (literal, bool) {
+	for _, lit := range d {
+		p.marks[lit] = true
+	}
+	defer func() {
+		for _, lit := range d {
+			p.marks[lit] = false
+		}
+	}()
+	l := litNone
+	for _, lit := range c {
+		switch {
+		case p.marks[lit]:
+		case p.marks[lit^1] && l == litNone:
+			l = lit
+		default:
+			return litNone, false
+		}
+	}
+	return l, true
+}
+
+// strengthen removes lit from the clause at index i.
+func (p *preprocessor) strengthen(i int, lit literal) {
+	sv := p.sv
+	cls := &p.clauses[i]
+	for j, q := range cls.lits {
+		if q == lit {
+			cls.lits = append(cls.lits[:j], cls.lits[j+1:]...)
+			break
+		}
+	}
+	occ := p.occurs[lit]
+	for j, k := range occ {
+		if k == i {
+			p.occurs[lit] = append(occ[:j], occ[j+1:]...)
+			break
+		}
+	}
+	sv.numStrengthened++
+	if sv.proof != nil {
+		sv.proof.add(sv.sourceLits(cls.lits))
+	}
+	p.touch(int(lit >> 1))
+	if len(cls.lits) == 1 {
+		p.removeClause(i)
+		p.assign(cls.lits[0])
+		return
+	}
+	cls.sig = clauseSig(cls.lits)
+	if !cls.queued {
+		cls.queued = true
+		p.subsumeQueue = append(p.subsumeQueue, i)
+	}
+}
+
+// eliminate eliminates v if it is worthwhile.
+func (p *preprocessor) eliminate(v int) {
+	sv := p.sv
+	if sv.sourceVars[v].assn != unassigned || sv.sourceVars[v].eliminated {
+		return
+	}
+	lit := literal(v) << 1
+	pos := p.occurrences(lit)
+	neg := p.occurrences(lit ^ 1)
+	if len(pos) == 0 || len(neg) == 0 {
+		// There are no resolvents, so this is the same as pure
+		// literal elimination.
+		p.eliminatePure(v)
+		return
+	}
+	if len(pos) > elimOccurLimit && len(neg) > elimOccurLimit {
+		return
+	}
+	var resolvents [][]literal
+	for _, i := range pos {
+		for _, j := range neg {
+			r, ok := p.resolve(p.clauses[i].lits, p.clauses[j].lits, v)
+			if !ok {
+				continue // tautology
+			}
+			if len(r) > elimResolventLimit || len(resolvents) == len(pos)+len(neg) {
+				return
+			}
+			resolvents = append(resolvents, append([]literal(nil), r...))
+		}
+	}
+
+	if sv.trace != nil {
+		sv.tracef("eliminate var=%d clauses=%d resolvents=%d",
+			sv.sourceVars[v].v, len(pos)+len(neg), len(resolvents))
+	}
+	sv.sourceVars[v].eliminated = true
+	sv.numEliminated++
+	for _, occ := range [][]int{pos, neg} {
+		for _, i := range occ {
+			sv.elimStack = append(sv.elimStack, elimClause{
+				witness: lit,
+				lits:    p.clauses[i].lits,
+			})
+			p.removeClause(i)
+		}
+		lit ^= 1
+	}
+	p.occurs[lit] = nil
+	p.occurs[lit^1] = nil
+	for _, r := range resolvents {
+		if sv.proof != nil {
+			sv.proof.add(sv.sourceLits(r))
+		}
+		p.addClause(r)
+	}
+	p.propagate()
+}
+
+// resolve computes the resolvent of the clauses c and d on the var v (which
+// must occur positively in one and negatively in the other). It returns false
+// if the resolvent is a tautology. The returned slice is only valid until the
+// next call to resolve.
+func (p *preprocessor) resolve(c, d []literal, v int) ([]literal, bool) {
+	r := p.resolvent[:0]
+	for _, lit := range c {
+		if int(lit>>1) != v {
+			p.marks[lit] = true
+			r = append(r, lit)
+		}
+	}
+	ok := true
+	for _, lit := range d {
+		if int(lit>>1) == v || p.marks[lit] {
+			continue
+		}
+		if p.marks[lit^1] {
+			ok = false
+			break
+		}
+		r = append(r, lit)
+	}
+	for _, lit := range c {
+		p.marks[lit] = false
+	}
+	p.resolvent = r
+	return r, ok
+}
+
+// eliminatePureLiterals removes the clauses containing pure literals: those
+// whose negations don't occur in the problem. Making a pure literal true
+// satisfies all of its clauses without affecting the others.
+func (p *preprocessor) eliminatePureLiterals() {
+	if p.unsat {
+		return
+	}
+	vars := make([]int, len(p.sv.sourceVars))
+	for v := range vars {
+		vars[v] = v
+	}
+	for len(vars) > 0 {
+		v := vars[len(vars)-1]
+		vars = vars[:len(vars)-1]
+		// Removing the clauses of a pure literal may make the
+		// literals of other vars in those clauses pure as well.
+		for _, i := range p.eliminatePure(v) {
+			for _, lit := range p.clauses[i].lits {
+				vars = append(vars, int(lit>>1))
+			}
+		}
+	}
+}
+
+// eliminatePure eliminates v if one of its literals is pure. It returns the
+// indexes of the removed clauses.
+func (p *preprocessor) eliminatePure(v int) []int {
+	sv := p.sv
+	if sv.sourceVars[v].assn != unassigned || sv.sourceVars[v].eliminated {
+		return nil
+	}
+	lit := literal(v) << 1
+	occ := p.occurrences(lit)
+	if len(occ) == 0 {
+		lit ^= 1
+		occ = p.occurrences(lit)
+	} else if len(p.occurrences(lit^1)) > 0 {
+		return nil
+	}
+	if len(occ) == 0 {
+		return nil
+	}
+
+	if sv.trace != nil {
+		sv.tracef("pure lit=%d clauses=%d", sv.sourceLits([]literal{lit})[0], len(occ))
+	}
+	sv.sourceVars[v].eliminated = true
+	sv.numPure++
+	for _, i := range occ {
+		sv.elimStack = append(sv.elimStack, elimClause{
+			witness: lit,
+			lits:    p.clauses[i].lits,
+		})
+		p.removeClause(i)
+	}
+	p.occurs[lit] = nil
+	return occ
+}
+
+// eliminateBlocked carries out blocked clause elimination. A clause c is
+// blocked on one of its literals l if every resolvent of c on l is a
+// tautology: that is, every clause containing ¬l also contains the negation
+// of some other literal of c. Given a solution to the rest of the problem
+// which doesn't satisfy c, making l true satisfies c and the clauses
+// containing ¬l are still satisfied by their other literals, so c can be
+// removed.
+func (p *preprocessor) eliminateBlocked() {
+	if p.unsat {
+		return
+	}
+	sv := p.sv
+	queued := make([]bool, len(p.clauses))
+	var queue []int
+	for i := range p.clauses {
+		if !p.clauses[i].removed {
+			queued[i] = true
+			queue = append(queue, i)
+		}
+	}
+	for len(queue) > 0 {
+		i := queue[len(queue)-1]
+		queue = queue[:len(queue)-1]
+		queued[i] = false
+		c := &p.clauses[i]
+		if c.removed {
+			continue
+		}
+		lit, ok := p.blocked(c.lits)
+		if !ok {
+			continue
+		}
+		if sv.trace != nil {
+			sv.tracef("blocked lit=%d clause=%s",
+				sv.sourceLits([]literal{lit})[0], sv.sourceClauseString(c.lits))
+		}
+		sv.numBlocked++
+		sv.elimStack = append(sv.elimStack, elimClause{
+			witness: lit,
+			lits:    c.lits,
+		})
+		p.removeClause(i)
+		// The clauses containing the negations of c's literals may
+		// have been blocked only by c.
+		for _, q := range c.lits {
+			for _, j := range p.occurrences(q ^ 1) {
+				if !queued[j] {
+					queued[j] = true
+					queue = append(queue, j)
+				}
+			}
+		}
+	}
+}
+
+// blocked reports whether clause c is blocked and, if so, returns the literal
+// it is blocked on.
+func (p *preprocessor) blocked(c []literal) (literal, bool) {
+	for _, lit := range c {
+		p.marks[lit] = true
+	}
+	defer func() {
+		for _, lit := range c {
+			p.marks[lit] = false
+		}
+	}()
+litLoop:
+	for _, lit := range c {
+		occ := p.occurrences(lit ^ 1)
+		if len(occ) > blockedOccurLimit {
+			continue
+		}
+	clauseLoop:
+		for _, j := range occ {
+			for _, q := range p.clauses[j].lits {
+				if q != lit^1 && p.marks[q^1] {
+					continue clauseLoop // tautological resolvent
+				}
+			}
+			continue litLoop
+		}
+		return lit, true
+	}
+	return litNone, false
+}
+
+// extendSolution extends a solution to the preprocessed problem (given as the
+// values of the source vars) to a solution to the original problem by
+// assigning the eliminated vars. It works backwards through sv.elimStack,
+// flipping the witness of each removed clause that isn't satisfied.
+func (sv *solver) extendSolution(vals []assnVal) {
+	for i, v := range sv.sourceVars {
+		if v.eliminated {
+			vals[i] = assnFalse
+		}
+	}
+elimLoop:
+	for i := len(sv.elimStack) - 1; i >= 0; i-- {
+		e := sv.elimStack[i]
+		for _, lit := range e.lits {
+			if vals[lit>>1] == lit.assn() {
+				continue elimLoop
+			}
+		}
+		vals[e.witness>>1] = e.witness.assn()
+	}
+}
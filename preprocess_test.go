@@ -0,0 +1,345 @@
+package saturday
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestEliminateVars(t *testing.T) {
+	// A chain of equivalences x1 ⇔ x2 ⇔ ... ⇔ x10 plus some clauses
+	// relating x1 and x10. Each var in the middle of the chain can be
+	// eliminated without adding clauses.
+	var chain [][]int
+	for v := 1; v < 10; v++ {
+		chain = append(chain, []int{-v, v + 1}, []int{v, -(v + 1)})
+	}
+	// Eliminating any var from this set of parity constraints (each var
+	// is in three of them) would add clauses, so none of them can be
+	// eliminated.
+	var parity [][]int
+	for _, vars := range [][3]int{{11, 12, 13}, {12, 13, 14}, {13, 14, 15}, {14, 15, 11}, {15, 11, 12}} {
+		parity = append(parity, xorClauses(vars[0], vars[1], vars[2])...)
+	}
+	for _, tt := range []struct {
+		extra      [][]int
+		want       [][]int // remaining clauses (if satisfiable)
+		eliminated int64
+		sat        bool
+	}{
+		{[][]int{{1, 10}}, nil, 9, true},
+		{[][]int{{-1, -10}}, nil, 9, true},
+		{[][]int{{1, 10}, {-1, 2, 3}}, nil, 9, true},
+		{[][]int{{1, 10}, {-1, -10}}, nil, 9, false},
+		{append([][]int{{1, 10}}, parity...), parity, 9, true},
+	} {
+		t.Run(fmt.Sprint(tt.extra), func(t *testing.T) {
+			problem := append(chain[:len(chain):len(chain)], tt.extra...)
+			sv := new(solver)
+			sv.init(nil)
+			sv.simplify(problem)
+			p := newPreprocessor(sv)
+			p.eliminateVars()
+			p.finish()
+			if sv.numEliminated != tt.eliminated {
+				t.Errorf("got %d eliminated vars; want %d", sv.numEliminated, tt.eliminated)
+			}
+			if !tt.sat {
+				if sv.simpleSat != assnFalse {
+					t.Errorf("variable elimination didn't find that the problem is unsatisfiable")
+				}
+				testFixtureUnsat(t, problem, nil)
+				return
+			}
+			if diff := cmp.Diff(sv.simplified, tt.want, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("after variable elimination (-got, +want):\n%s", diff)
+			}
+			testFixtureSat(t, problem, nil)
+		})
+	}
+}
+
+// xorClauses returns the clauses encoding a ⊕ b ⊕ c.
+func xorClauses(a, b, c int) [][]int {
+	var clauses [][]int
+	for signs := 0; signs < 8; signs++ {
+		cls := []int{a, b, c}
+		neg := 0
+		for i := range cls {
+			if signs>>i&1 == 1 {
+				cls[i] = -cls[i]
+				neg++
+			}
+		}
+		// The only assignment that falsifies cls makes neg of the
+		// vars true, so the clauses with even neg rule out the
+		// assignments with an even number of true vars.
+		if neg%2 == 0 {
+			clauses = append(clauses, cls)
+		}
+	}
+	return clauses
+}
+
+// TestEliminateVarsSolve checks that variable elimination happens as part of
+// Solve's preprocessing (after the other passes) and that the solution is
+// extended to the eliminated vars.
+func TestEliminateVarsSolve(t *testing.T) {
+	// A chain of implications x1 ⇒ x2 ⇒ ... ⇒ x10 plus some clauses
+	// relating x1 and x10 that keep the vars from being pure.
+	var problem [][]int
+	for v := 1; v < 10; v++ {
+		problem = append(problem, []int{-v, v + 1})
+	}
+	for _, extra := range [][][]int{
+		{{1, 11}, {-10, 11}, {-11, 12, 13}, {-11, -12, -13}, {-1, 12, -13}, {10, -12, 13}},
+		{
+			{1, 11, 12}, {-1, -11, 12}, {-1, 11, -12}, {1, -11, -12},
+			{10, 11, 12}, {-10, -11, 12}, {-10, 11, -12}, {10, -11, -12},
+		},
+	} {
+		t.Run(fmt.Sprint(extra), func(t *testing.T) {
+			problem := append(problem[:len(problem):len(problem)], extra...)
+			soln, stats, ok := Solve(problem)
+			if !ok {
+				t.Fatal("got UNSAT")
+			}
+			if stats.EliminatedVars == 0 {
+				t.Error("no vars were eliminated")
+			}
+			if !solutionIsValid(problem, soln) {
+				t.Fatalf("got invalid solution %v", soln)
+			}
+			vars := make(map[int]bool)
+			for _, v := range soln {
+				vars[abs(v)] = true
+			}
+			for _, cls := range problem {
+				for _, v := range cls {
+					if !vars[abs(v)] {
+						t.Fatalf("solution %v has no value for %d", soln, abs(v))
+					}
+				}
+			}
+		})
+	}
+}
+
+// TestPreprocessRandomized checks that preprocessing doesn't change the
+// result for random 3-SAT problems (about half of which are unsatisfiable),
+// that the solutions are extended correctly, and that the proofs are valid.
+func TestPreprocessRandomized(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	for i := 0; i < 1000; i++ {
+		problem := makeRandom3SAT(rng, 20)
+		_, _, want := SolveWithOptions(problem, &Options{NoPreprocess: true})
+		soln, _, got := Solve(problem)
+		if got != want {
+			t.Fatalf("with preprocessing, got sat=%t; want %t for %v", got, want, problem)
+		}
+		if got && !solutionIsValid(problem, soln) {
+			t.Fatalf("got invalid solution %v for %v", soln, problem)
+		}
+		if !got {
+			testFixtureUnsat(t, problem, nil)
+		}
+	}
+}
+
+// makeRandom3SAT makes a random 3-SAT problem with the clause/var ratio near
+// the phase transition (4.26).
+func makeRandom3SAT(rng *rand.Rand, numVars int) [][]int {
+	var problem [][]int
+	for j := 0; j < numVars*426/100; j++ {
+		var cls []int
+		for _, v := range rng.Perm(numVars)[:3] {
+			v++
+			if rng.Intn(2) == 0 {
+				v = -v
+			}
+			cls = append(cls, v)
+		}
+		problem = append(problem, cls)
+	}
+	return problem
+}
+
+func TestSubsume(t *testing.T) {
+	for _, tt := range []struct {
+		problem      [][]int
+		want         [][]int
+		subsumed     int64
+		strengthened int64
+	}{
+		{
+			problem:  [][]int{{1, 2}, {1, 2, 3}, {3, -4, 2, 1}, {-1, -2}},
+			want:     [][]int{{1, 2}, {-1, -2}},
+			subsumed: 2,
+		},
+		{
+			// Duplicate clauses subsume each other.
+			problem:  [][]int{{1, 2, 3}, {3, 2, 1}, {-1, -2}},
+			want:     [][]int{{3, 2, 1}, {-1, -2}},
+			subsumed: 1,
+		},
+		{
+			// 1 ∨ 2 and ¬1 ∨ 2 ∨ 3 resolve to 2 ∨ 3, which
+			// replaces the latter.
+			problem:      [][]int{{1, 2}, {-1, 2, 3}, {-2, -3}},
+			want:         [][]int{{1, 2}, {2, 3}, {-2, -3}},
+			strengthened: 1,
+		},
+		{
+			// After 1 ∨ 2 ∨ 3 strengthens ¬1 ∨ 2 ∨ 3 to 2 ∨ 3,
+			// the result subsumes the former.
+			problem:      [][]int{{1, 2, 3}, {-1, 2, 3}, {-2, -3}},
+			want:         [][]int{{2, 3}, {-2, -3}},
+			subsumed:     1,
+			strengthened: 1,
+		},
+		{
+			// Strengthening can produce a unit clause.
+			problem:      [][]int{{1, 2}, {1, -2}, {-1, 3, 4}, {-3, -4}},
+			want:         [][]int{{3, 4}, {-3, -4}},
+			strengthened: 1,
+		},
+	} {
+		t.Run(fmt.Sprint(tt.problem), func(t *testing.T) {
+			sv := new(solver)
+			sv.init(nil)
+			sv.simplify(tt.problem)
+			p := newPreprocessor(sv)
+			p.subsume()
+			p.finish()
+			if diff := cmp.Diff(sv.simplified, tt.want, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("after subsumption (-got, +want):\n%s", diff)
+			}
+			if sv.numSubsumed != tt.subsumed {
+				t.Errorf("got %d subsumed clauses; want %d", sv.numSubsumed, tt.subsumed)
+			}
+			if sv.numStrengthened != tt.strengthened {
+				t.Errorf("got %d strengthened literals; want %d", sv.numStrengthened, tt.strengthened)
+			}
+		})
+	}
+}
+
+func TestSubstituteEquivalences(t *testing.T) {
+	// 1, 2, and 3 are equivalent; so are 4 and ¬5.
+	problem := [][]int{
+		{-1, 2}, {1, -2}, {2, -3}, {-2, 3},
+		{4, 5}, {-4, -5},
+		{1, 4, 6}, {-3, -5, 6}, {-2, -6, 7}, {-7, -6},
+	}
+	sv := new(solver)
+	sv.init(nil)
+	sv.simplify(problem)
+	p := newPreprocessor(sv)
+	p.substituteEquivalences()
+	p.finish()
+	want := [][]int{{1, 4, 6}, {-7, -6}, {-1, 4, 6}, {-1, -6, 7}}
+	if diff := cmp.Diff(sv.simplified, want); diff != "" {
+		t.Errorf("after substitution (-got, +want):\n%s", diff)
+	}
+	if sv.numSubstituted != 3 {
+		t.Errorf("got %d substituted vars; want 3", sv.numSubstituted)
+	}
+
+	testFixtureSat(t, problem, nil)
+	// Now add 1 ⇔ ¬3, which contradicts the other equivalences.
+	problem = append(problem, []int{1, 3}, []int{-1, -3})
+	testFixtureUnsat(t, problem, nil)
+}
+
+func TestEliminatePureLiterals(t *testing.T) {
+	for _, tt := range []struct {
+		problem [][]int
+		want    [][]int
+		pure    int64
+	}{
+		{
+			problem: [][]int{{1, 2}, {1, -3}, {-2, 3}, {2, -3}},
+			want:    [][]int{{-2, 3}, {2, -3}},
+			pure:    1,
+		},
+		{
+			// Once the clause containing 1 is removed, ¬2 is pure.
+			problem: [][]int{{1, 2}, {-2, 3}, {-3, -2, 4}, {3, -4}, {-3, 4}},
+			want:    [][]int{{3, -4}, {-3, 4}},
+			pure:    2,
+		},
+	} {
+		t.Run(fmt.Sprint(tt.problem), func(t *testing.T) {
+			sv := new(solver)
+			sv.init(nil)
+			sv.simplify(tt.problem)
+			p := newPreprocessor(sv)
+			p.eliminatePureLiterals()
+			p.finish()
+			if diff := cmp.Diff(sv.simplified, tt.want, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("after pure literal elimination (-got, +want):\n%s", diff)
+			}
+			if sv.numPure != tt.pure {
+				t.Errorf("got %d pure literals; want %d", sv.numPure, tt.pure)
+			}
+			testFixtureSat(t, tt.problem, nil)
+		})
+	}
+}
+
+func TestEliminateBlocked(t *testing.T) {
+	for _, tt := range []struct {
+		problem [][]int
+		want    [][]int
+		blocked int64
+		sat     bool
+	}{
+		{
+			// Every clause of an equivalence is blocked.
+			problem: [][]int{{1, 2}, {1, -3}, {-2, 3}, {2, -3}},
+			want:    [][]int{},
+			blocked: 4,
+			sat:     true,
+		},
+		{
+			// The definition of 3 ⇔ 1 ∧ 2 (from the Tseitin
+			// encoding) where 3 is only used positively.
+			problem: [][]int{
+				{-3, 1}, {-3, 2}, {3, -1, -2},
+				{3, 4}, {-4, -1}, {4, 1}, {-1, 2, 5}, {1, -2, -5}, {-5, 4},
+			},
+			want:    [][]int{{-3, 1}, {3, 4}, {-4, -1}},
+			blocked: 6,
+			sat:     true,
+		},
+		{
+			problem: [][]int{{1, 2}, {-1, 2}, {1, -2}, {-1, -2, 3}, {-1, -2, -3}},
+			want:    [][]int{{1, 2}, {-1, 2}, {1, -2}, {-1, -2, 3}, {-1, -2, -3}},
+			blocked: 0,
+			sat:     false,
+		},
+	} {
+		t.Run(fmt.Sprint(tt.problem), func(t *testing.T) {
+			sv := new(solver)
+			sv.init(nil)
+			sv.simplify(tt.problem)
+			p := newPreprocessor(sv)
+			p.eliminateBlocked()
+			p.finish()
+			if diff := cmp.Diff(sv.simplified, tt.want, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("after blocked clause elimination (-got, +want):\n%s", diff)
+			}
+			if sv.numBlocked != tt.blocked {
+				t.Errorf("got %d blocked clauses; want %d", sv.numBlocked, tt.blocked)
+			}
+			if tt.sat {
+				testFixtureSat(t, tt.problem, nil)
+			} else {
+				testFixtureUnsat(t, tt.problem, nil)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package saturday
+
+// probeImplicationLimit bounds the work done by probe: it stops once it has
+// made this many implications.
+const probeImplicationLimit = 1 << 20
+
+// probe carries out failed literal probing. It tries assigning each literal in
+// turn (at decision level 1) and propagates the consequences using bcp:
+//
+//   - If a literal l leads to a conflict, then ¬l must hold, so it is
+//     assigned at level 0. (This is a failed literal.)
+//   - If both l and ¬l imply some literal, then that literal must hold, so it
+//     is assigned at level 0 as well.
+//
+// The solver must be at level 0. If probing solves the problem (that is, it
+// finds that the problem is unsatisfiable or it assigns every var), it sets
+// sv.simpleSat accordingly.
+func (sv *solver) probe() {
+	if sv.unsat {
+		return
+	}
+	if !sv.bcp() {
+		sv.probeUnsat()
+		return
+	}
+	// Probing shouldn't affect the saved phases.
+	phases := append([]assnVal(nil), sv.phases...)
+	defer copy(sv.phases, phases)
+
+	implied := make([]bool, len(sv.watches)) // literals implied by v
+	var posImplied, negImplied, common []literal
+	limit := sv.numImplications + probeImplicationLimit
+	for v := range sv.assignments {
+		if sv.numImplications >= limit {
+			break
+		}
+		if sv.assignments[v] != unassigned {
+			continue
+		}
+		lit := literal(v) << 1
+		var ok bool
+		posImplied, ok = sv.probeLit(lit, posImplied[:0])
+		if !ok {
+			if !sv.probeAssign(lit ^ 1) {
+				return
+			}
+			continue
+		}
+		for _, q := range posImplied {
+			implied[q] = true
+		}
+		negImplied, ok = sv.probeLit(lit^1, negImplied[:0])
+		common = common[:0]
+		if ok {
+			for _, q := range negImplied {
+				if implied[q] {
+					common = append(common, q)
+				}
+			}
+		}
+		for _, q := range posImplied {
+			implied[q] = false
+		}
+		if !ok {
+			if !sv.probeAssign(lit) {
+				return
+			}
+			continue
+		}
+		for _, q := range common {
+			// To make q easy to check, give the proof v → q and
+			// ¬v → q first.
+			sv.proofAdd([]literal{lit ^ 1, q})
+			sv.proofAdd([]literal{lit, q})
+			if !sv.probeAssign(q) {
+				return
+			}
+		}
+	}
+	if len(sv.implications) == len(sv.assignments) {
+		sv.simpleSat = assnTrue
+	}
+}
+
+// probeLit assigns lit at level 1 and propagates it. If that leads to a
+// conflict, probeLit returns false. Otherwise, it appends the implied literals
+// to implied and returns the result. Either way, it backtracks to level 0
+// afterwards.
+func (sv *solver) probeLit(lit literal, implied []literal) ([]literal, bool) {
+	if sv.trace != nil {
+		sv.tracef("probe lit=%d", sv.origLit(lit))
+	}
+	start := len(sv.implications)
+	sv.decisions = append(sv.decisions, decision{
+		implicationIdx: start,
+		v:              int(lit >> 1),
+	})
+	sv.assign(lit, -1)
+	ok := sv.bcp()
+	if ok {
+		implied = append(implied, sv.implications[start+1:]...)
+	}
+	sv.backtrack(0)
+	return implied, ok
+}
+
+// probeAssign assigns lit, which probing has found must hold, at level 0 and
+// propagates it. It returns false if that shows the problem to be
+// unsatisfiable.
+func (sv *solver) probeAssign(lit literal) bool {
+	switch sv.assignments[lit>>1] {
+	case lit.assn():
+		// Already implied by a previous unit.
+		return true
+	case unassigned:
+	default:
+		sv.proofAdd([]literal{lit})
+		sv.probeUnsat()
+		return false
+	}
+	if sv.trace != nil {
+		sv.tracef("probe-unit lit=%d", sv.origLit(lit))
+	}
+	sv.proofAdd([]literal{lit})
+	sv.assign(lit, -1)
+	sv.numProbed++
+	if !sv.bcp() {
+		sv.probeUnsat()
+		return false
+	}
+	return true
+}
+
+func (sv *solver) probeUnsat() {
+	if sv.trace != nil {
+		sv.tracef("simplify-unsat reason=probe")
+	}
+	sv.simpleSat = assnFalse
+}
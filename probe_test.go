@@ -0,0 +1,61 @@
+package saturday
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestProbe(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		problem [][]int
+		want    []int // literals assigned at level 0
+		sat     assnVal
+	}{
+		{
+			name:    "failed literal",
+			problem: [][]int{{-1, 2}, {-1, 3}, {-2, -3, 4}, {-1, -4}, {1, 5, 6}, {-5, -6}},
+			want:    []int{-1},
+			sat:     unassigned,
+		},
+		{
+			name:    "common implication",
+			problem: [][]int{{1, 2}, {-1, 3}, {-2, 3}, {-3, 4, 5}, {-4, -5}},
+			want:    []int{3},
+			sat:     unassigned,
+		},
+		{
+			name:    "solved",
+			problem: [][]int{{1, 2}, {-1, 2}, {-2, 3}, {-3, 1}},
+			want:    []int{1, 2, 3},
+			sat:     assnTrue,
+		},
+		{
+			name:    "unsat",
+			problem: [][]int{{1, 2}, {-1, 2}, {1, -2}, {-1, -2}},
+			sat:     assnFalse,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			sv := newSolver(tt.problem, &Options{NoPreprocess: true})
+			sv.probe()
+			if sv.simpleSat != tt.sat {
+				t.Fatalf("got simpleSat=%d; want %d", sv.simpleSat, tt.sat)
+			}
+			if tt.sat == assnFalse {
+				return
+			}
+			var got []int
+			for _, lit := range sv.implications {
+				got = append(got, sv.origLit(lit))
+			}
+			sort.Ints(got)
+			sort.Ints(tt.want)
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("level 0 assignments (-got, +want):\n%s", diff)
+			}
+		})
+	}
+}
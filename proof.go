@@ -0,0 +1,114 @@
+package saturday
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+)
+
+// A ProofFormat is an encoding for DRAT proofs.
+type ProofFormat uint8
+
+const (
+	// ProofText is the textual DRAT format: each line is a clause in the
+	// same format as in DIMACS CNF, optionally prefixed by "d" to indicate
+	// a deleted clause.
+	ProofText ProofFormat = iota
+	// ProofBinary is the more compact binary DRAT format understood by
+	// drat-trim and other proof checkers.
+	ProofBinary
+)
+
+// A proofWriter streams a DRAT proof: the sequence of clauses that the solver
+// learned (each of which is implied by the formula plus the previous clauses)
+// and deleted, ending with the empty clause if the formula is unsatisfiable.
+type proofWriter struct {
+	w      *bufio.Writer
+	format ProofFormat
+	buf    []byte
+	err    error // first write error; once set, nothing more is written
+}
+
+func newProofWriter(w io.Writer, format ProofFormat) *proofWriter {
+	return &proofWriter{
+		w:      bufio.NewWriter(w),
+		format: format,
+	}
+}
+
+func (pw *proofWriter) add(lits []int)    { pw.write(false, lits) }
+func (pw *proofWriter) delete(lits []int) { pw.write(true, lits) }
+
+func (pw *proofWriter) write(del bool, lits []int) {
+	if pw.err != nil {
+		return
+	}
+	b := pw.buf[:0]
+	switch pw.format {
+	case ProofText:
+		if del {
+			b = append(b, "d "...)
+		}
+		for _, v := range lits {
+			b = strconv.AppendInt(b, int64(v), 10)
+			b = append(b, ' ')
+		}
+		b = append(b, "0\n"...)
+	case ProofBinary:
+		if del {
+			b = append(b, 'd')
+		} else {
+			b = append(b, 'a')
+		}
+		for _, v := range lits {
+			// Each literal is mapped to an unsigned integer
+			// (2v for v and 2v+1 for -v) which is encoded as
+			// a little-endian varint with 7 bits per byte.
+			var u uint64
+			if v < 0 {
+				u = uint64(-v)<<1 | 1
+			} else {
+				u = uint64(v) << 1
+			}
+			for u > 0x7f {
+				b = append(b, byte(u)|0x80)
+				u >>= 7
+			}
+			b = append(b, byte(u))
+		}
+		b = append(b, 0)
+	default:
+		panic("unknown proof format")
+	}
+	pw.buf = b
+	_, pw.err = pw.w.Write(b)
+}
+
+func (pw *proofWriter) flush() error {
+	if pw.err != nil {
+		return pw.err
+	}
+	pw.err = pw.w.Flush()
+	return pw.err
+}
+
+func (sv *solver) proofAdd(lits []literal) {
+	if sv.proof != nil {
+		sv.proof.add(sv.proofLits(lits))
+	}
+}
+
+func (sv *solver) proofDelete(lits []literal) {
+	if sv.proof != nil {
+		sv.proof.delete(sv.proofLits(lits))
+	}
+}
+
+func (sv *solver) proofLits(lits []literal) []int {
+	s := sv.proofBuf[:0]
+	for _, lit := range lits {
+		s = append(s, sv.origLit(lit))
+	}
+	sv.proofBuf = s
+	return s
+}
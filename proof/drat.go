@@ -0,0 +1,382 @@
+package proof
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// CheckDRAT checks that proof is a valid DRAT proof that cnf is
+// unsatisfiable. It returns nil if the proof is valid.
+//
+// A DRAT proof is a sequence of clauses that are added to (lemmas) or deleted
+// from the formula. Each lemma must be a reverse unit propagation (RUP)
+// consequence of the formula so far or else a resolution asymmetric tautology
+// (RAT) on its first literal. The proof is complete once the formula (plus
+// lemmas) is refuted by unit propagation alone; typically this is indicated by
+// adding the empty clause.
+//
+// CheckDRAT uses backward checking: it first finds the final conflict and then
+// works backwards through the proof, checking only those lemmas that are
+// needed (directly or indirectly) to derive it. Unit propagation prefers
+// clauses that are already known to be needed (core-first propagation), which
+// keeps the set of lemmas to check small.
+//
+// As with drat-trim, deletions of unit clauses are ignored (solvers often
+// delete unit clauses that are still in use as reasons), as are deletions of
+// clauses that don't exist.
+func CheckDRAT(cnf [][]int, proof io.Reader) error {
+	c := newDRATChecker()
+	for _, clause := range cnf {
+		lits := toLits(clause)
+		if len(lits) == 0 {
+			return nil // trivially unsatisfiable
+		}
+		c.add(lits)
+	}
+
+	// Go forward through the proof to find the state of the formula at
+	// the point where it becomes refutable (which is usually at the first
+	// empty clause).
+	t, err := newTokenizer(proof)
+	if err != nil {
+		return err
+	}
+	type op struct {
+		clause int
+		del    bool
+		step   int
+	}
+	var ops []op
+	for {
+		t.step++
+		marker, err := t.next()
+		if err != nil {
+			return err
+		}
+		if marker == 0 {
+			break
+		}
+		clause, err := t.ints()
+		if err != nil {
+			return err
+		}
+		lits := toLits(clause)
+		if marker == 'd' {
+			i := c.find(lits)
+			if i < 0 || len(lits) == 1 {
+				continue
+			}
+			c.clauses[i].active = false
+			ops = append(ops, op{clause: i, del: true, step: t.step})
+			continue
+		}
+		if len(lits) == 0 {
+			break
+		}
+		i := c.add(lits)
+		ops = append(ops, op{clause: i, step: t.step})
+	}
+	if !c.refute(nil) {
+		return errors.New("proof doesn't derive a conflict")
+	}
+
+	// Now go backward, checking the lemmas that were used.
+	for j := len(ops) - 1; j >= 0; j-- {
+		o := ops[j]
+		cls := &c.clauses[o.clause]
+		if o.del {
+			cls.active = true
+			continue
+		}
+		cls.active = false
+		if !cls.core {
+			continue
+		}
+		if !c.checkLemma(o.clause) {
+			return fmt.Errorf("step %d: lemma %s is not RUP or RAT", o.step, litsString(cls.lits))
+		}
+	}
+	return nil
+}
+
+type dratClause struct {
+	lits   []literal // for clauses longer than 1, lits[0] and lits[1] are watched
+	pivot  literal   // the first literal, as written in the proof
+	active bool
+	core   bool // needed for the proof
+}
+
+type dratChecker struct {
+	assignment
+	clauses []dratClause
+	units   []int   // unit clauses
+	watches [][]int // clauses watching each literal
+	byLits  map[string][]int
+
+	// Propagation processes the trail twice: first using only core
+	// clauses (coreHead) and then using the rest (allHead).
+	coreHead int
+	allHead  int
+
+	seen []bool // scratch space for analyze (one per var)
+}
+
+func newDRATChecker() *dratChecker {
+	return &dratChecker{byLits: make(map[string][]int)}
+}
+
+func (c *dratChecker) grow(v int) {
+	c.assignment.grow(v)
+	for len(c.seen) <= v {
+		c.seen = append(c.seen, false)
+		c.watches = append(c.watches, nil, nil)
+	}
+}
+
+func clauseKey(lits []literal) string {
+	sorted := append([]literal(nil), lits...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	var b strings.Builder
+	for _, lit := range sorted {
+		fmt.Fprintf(&b, "%d ", lit)
+	}
+	return b.String()
+}
+
+// add adds an active clause and returns its index.
+func (c *dratChecker) add(lits []literal) int {
+	for _, lit := range lits {
+		c.grow(int(lit >> 1))
+	}
+	i := len(c.clauses)
+	c.clauses = append(c.clauses, dratClause{lits: lits, pivot: lits[0], active: true})
+	if len(lits) == 1 {
+		c.units = append(c.units, i)
+	} else {
+		c.watches[lits[0]] = append(c.watches[lits[0]], i)
+		c.watches[lits[1]] = append(c.watches[lits[1]], i)
+	}
+	key := clauseKey(lits)
+	c.byLits[key] = append(c.byLits[key], i)
+	return i
+}
+
+// find returns the index of the most recently added active clause with the
+// given literals (in any order), or -1 if there is none.
+func (c *dratChecker) find(lits []literal) int {
+	key := clauseKey(lits)
+	idxs := c.byLits[key]
+	for j := len(idxs) - 1; j >= 0; j-- {
+		if c.clauses[idxs[j]].active {
+			i := idxs[j]
+			c.byLits[key] = append(idxs[:j], idxs[j+1:]...)
+			return i
+		}
+	}
+	return -1
+}
+
+func (c *dratChecker) reset() {
+	c.backtrack(0)
+	c.coreHead = 0
+	c.allHead = 0
+}
+
+func (c *dratChecker) backtrack(n int) {
+	c.assignment.backtrack(n)
+	if c.coreHead > n {
+		c.coreHead = n
+	}
+	if c.allHead > n {
+		c.allHead = n
+	}
+}
+
+// refute assigns the negation of lits (along with the active unit clauses)
+// and reports whether unit propagation leads to a conflict. If it does, the
+// clauses involved are marked as core.
+func (c *dratChecker) refute(lits []literal) bool {
+	c.reset()
+	for _, i := range c.units {
+		cls := &c.clauses[i]
+		if !cls.active {
+			continue
+		}
+		lit := cls.lits[0]
+		switch c.value(lit) {
+		case 0:
+			c.assign(lit, i)
+		case -1:
+			cls.core = true
+			c.analyze(int(lit >> 1))
+			return true
+		}
+	}
+	for _, lit := range lits {
+		switch c.value(lit) {
+		case 0:
+			c.assign(lit^1, -1)
+		case 1:
+			c.analyze(int(lit >> 1))
+			return true
+		}
+	}
+	return c.propagateAndAnalyze()
+}
+
+func (c *dratChecker) propagateAndAnalyze() bool {
+	confl := c.propagate()
+	if confl < 0 {
+		return false
+	}
+	c.clauses[confl].core = true
+	for _, lit := range c.clauses[confl].lits {
+		c.seen[lit>>1] = true
+	}
+	c.analyze(-1)
+	return true
+}
+
+// analyze marks as core the reasons for every var marked as seen (plus v, if
+// it is not -1) as well as, recursively, the reasons for the literals in those
+// reasons.
+func (c *dratChecker) analyze(v int) {
+	if v >= 0 {
+		c.seen[v] = true
+	}
+	for i := len(c.trail) - 1; i >= 0; i-- {
+		lit := c.trail[i]
+		v := lit >> 1
+		if !c.seen[v] {
+			continue
+		}
+		c.seen[v] = false
+		r := c.reasons[v]
+		if r < 0 {
+			continue
+		}
+		c.clauses[r].core = true
+		for _, q := range c.clauses[r].lits {
+			if q != lit {
+				c.seen[q>>1] = true
+			}
+		}
+	}
+}
+
+// propagate does unit propagation, preferring core clauses. It returns the
+// index of a conflicting clause or -1 if there is no conflict.
+func (c *dratChecker) propagate() int {
+	for {
+		if c.coreHead < len(c.trail) {
+			lit := c.trail[c.coreHead]
+			c.coreHead++
+			if confl := c.propagateLit(lit, true); confl >= 0 {
+				return confl
+			}
+			continue
+		}
+		if c.allHead < len(c.trail) {
+			lit := c.trail[c.allHead]
+			c.allHead++
+			if confl := c.propagateLit(lit, false); confl >= 0 {
+				return confl
+			}
+			continue
+		}
+		return -1
+	}
+}
+
+// propagateLit visits the active clauses watching the negation of lit that
+// are core (or not core, if core is false).
+func (c *dratChecker) propagateLit(lit literal, core bool) int {
+	neg := lit ^ 1
+	watches := c.watches[neg]
+	defer func() { c.watches[neg] = watches }()
+watchLoop:
+	for i := 0; i < len(watches); {
+		idx := watches[i]
+		cls := &c.clauses[idx]
+		if !cls.active || cls.core != core {
+			i++
+			continue
+		}
+		if cls.lits[0] == neg {
+			cls.lits[0], cls.lits[1] = cls.lits[1], cls.lits[0]
+		}
+		if c.value(cls.lits[0]) == 1 {
+			i++
+			continue
+		}
+		for j := 2; j < len(cls.lits); j++ {
+			if c.value(cls.lits[j]) != -1 {
+				cls.lits[1], cls.lits[j] = cls.lits[j], cls.lits[1]
+				c.watches[cls.lits[1]] = append(c.watches[cls.lits[1]], idx)
+				watches[i] = watches[len(watches)-1]
+				watches = watches[:len(watches)-1]
+				continue watchLoop
+			}
+		}
+		i++
+		if c.value(cls.lits[0]) == -1 {
+			return idx
+		}
+		c.assign(cls.lits[0], idx)
+	}
+	return -1
+}
+
+// checkLemma checks that the (inactive) clause i is RUP or RAT with respect to
+// the active clauses.
+func (c *dratChecker) checkLemma(i int) bool {
+	lits := c.clauses[i].lits
+	if c.refute(lits) {
+		return true
+	}
+	if len(lits) == 0 {
+		return false
+	}
+	// Check RAT on the first literal: for each active clause D containing
+	// its negation, the resolvent of the lemma and D must be RUP.
+	pivot := c.clauses[i].pivot
+	n := len(c.trail)
+	for j := range c.clauses {
+		d := &c.clauses[j]
+		if !d.active || !containsLit(d.lits, pivot^1) {
+			continue
+		}
+		d.core = true
+		ok := false
+		for _, lit := range d.lits {
+			if lit == pivot^1 {
+				continue
+			}
+			if c.value(lit) == 1 {
+				c.analyze(int(lit >> 1))
+				ok = true
+				break
+			}
+			if c.value(lit) == 0 {
+				c.assign(lit^1, -1)
+			}
+		}
+		if !ok && !c.propagateAndAnalyze() {
+			return false
+		}
+		c.backtrack(n)
+	}
+	return true
+}
+
+func containsLit(lits []literal, lit literal) bool {
+	for _, l := range lits {
+		if l == lit {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,233 @@
+package proof
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// CheckLRAT checks that proof is a valid LRAT proof that cnf is
+// unsatisfiable. It returns nil if the proof is valid.
+//
+// The clauses of cnf have the IDs 1 through len(cnf). Each step of an LRAT
+// proof either deletes clauses by ID or adds a clause with a new ID along
+// with a list of hints: the IDs of the clauses that become unit (and finally
+// conflicting) when the negation of the new clause is assigned. A RAT step
+// adds a negative hint -j for each clause j containing the negation of the
+// pivot (the first literal of the new clause), followed by the hints
+// needed to refute the resolvent. The proof is complete once the empty
+// clause is added.
+//
+// Because the hints say exactly which clauses are needed, CheckLRAT checks
+// each step as it is read, in a single forward pass.
+func CheckLRAT(cnf [][]int, proof io.Reader) error {
+	c := &lratChecker{clauses: make(map[int][]literal)}
+	for i, clause := range cnf {
+		lits := toLits(clause)
+		if len(lits) == 0 {
+			return nil // trivially unsatisfiable
+		}
+		c.add(i+1, lits)
+	}
+
+	t, err := newTokenizer(proof)
+	if err != nil {
+		return err
+	}
+	for {
+		t.step++
+		marker, err := t.next()
+		if err != nil {
+			return err
+		}
+		if marker == 0 {
+			return errors.New("proof doesn't derive the empty clause")
+		}
+		var id int
+		if t.binary {
+			if marker == 'a' {
+				if id, err = t.id(); err != nil {
+					return err
+				}
+			}
+		} else {
+			if marker == 'd' {
+				return fmt.Errorf("step %d: %s: missing clause ID", t.step, errMalformed)
+			}
+			if id, err = t.id(); err != nil {
+				return err
+			}
+			if marker, err = t.next(); err != nil {
+				return err
+			}
+		}
+
+		if marker == 'd' {
+			ids, err := t.ints()
+			if err != nil {
+				return err
+			}
+			for _, id := range ids {
+				if _, ok := c.clauses[id]; !ok {
+					return fmt.Errorf("step %d: deleted clause %d does not exist", t.step, id)
+				}
+				delete(c.clauses, id)
+			}
+			continue
+		}
+
+		clause, err := t.ints()
+		if err != nil {
+			return err
+		}
+		hints, err := t.ints()
+		if err != nil {
+			return err
+		}
+		if _, ok := c.clauses[id]; ok || id == 0 {
+			return fmt.Errorf("step %d: clause ID %d is already in use", t.step, id)
+		}
+		lits := toLits(clause)
+		if err := c.check(lits, hints); err != nil {
+			return fmt.Errorf("step %d: lemma %d %s: %s", t.step, id, litsString(lits), err)
+		}
+		if len(lits) == 0 {
+			return nil
+		}
+		c.add(id, lits)
+	}
+}
+
+type lratChecker struct {
+	assignment
+	clauses map[int][]literal
+}
+
+func (c *lratChecker) add(id int, lits []literal) {
+	for _, lit := range lits {
+		c.grow(int(lit >> 1))
+	}
+	c.clauses[id] = lits
+}
+
+// check checks that lits is implied by the current clauses using hints.
+func (c *lratChecker) check(lits []literal, hints []int) error {
+	for _, lit := range lits {
+		c.grow(int(lit >> 1))
+	}
+	defer c.backtrack(0)
+	for _, lit := range lits {
+		switch c.value(lit) {
+		case 0:
+			c.assign(lit^1, -1)
+		case 1:
+			return nil // tautology
+		}
+	}
+
+	// Process the RUP hints (everything before the first negative hint).
+	i := 0
+	for ; i < len(hints) && hints[i] > 0; i++ {
+		conflict, err := c.propagateHint(hints[i])
+		if err != nil {
+			return err
+		}
+		if conflict {
+			return nil
+		}
+	}
+	if i == len(hints) {
+		return errors.New("hints do not lead to a conflict")
+	}
+	if len(lits) == 0 {
+		return errors.New("RAT hints given for the empty clause")
+	}
+
+	// Check RAT on the pivot: each clause containing its negation must
+	// have a group of hints refuting the resolvent.
+	neg := lits[0] ^ 1
+	groups := make(map[int][]int)
+	for i < len(hints) {
+		j := -hints[i]
+		if j <= 0 {
+			return fmt.Errorf("%s: unexpected hint %d", errMalformed, hints[i])
+		}
+		if _, ok := groups[j]; ok {
+			return fmt.Errorf("%s: duplicate RAT hint %d", errMalformed, -j)
+		}
+		start := i + 1
+		for i = start; i < len(hints) && hints[i] > 0; i++ {
+		}
+		groups[j] = hints[start:i]
+	}
+	for j := range groups {
+		if _, ok := c.clauses[j]; !ok {
+			return fmt.Errorf("RAT hint %d refers to a nonexistent clause", -j)
+		}
+	}
+	n := len(c.trail)
+	for id, d := range c.clauses {
+		if !containsLit(d, neg) {
+			continue
+		}
+		if err := c.checkResolvent(id, d, neg, groups[id]); err != nil {
+			return err
+		}
+		c.backtrack(n)
+	}
+	return nil
+}
+
+// checkResolvent checks that the resolvent of the lemma (whose negation is
+// currently assigned) and clause id (d) on the negated pivot neg is RUP using
+// hints.
+func (c *lratChecker) checkResolvent(id int, d []literal, neg literal, hints []int) error {
+	for _, lit := range d {
+		if lit == neg {
+			continue
+		}
+		switch c.value(lit) {
+		case 0:
+			c.assign(lit^1, -1)
+		case 1:
+			return nil // the resolvent is a tautology
+		}
+	}
+	for _, h := range hints {
+		conflict, err := c.propagateHint(h)
+		if err != nil {
+			return err
+		}
+		if conflict {
+			return nil
+		}
+	}
+	return fmt.Errorf("hints for resolvent with clause %d do not lead to a conflict", id)
+}
+
+// propagateHint checks that the hinted clause is either unit (in which case
+// its remaining literal is assigned) or conflicting under the current
+// assignment.
+func (c *lratChecker) propagateHint(id int) (conflict bool, err error) {
+	lits, ok := c.clauses[id]
+	if !ok {
+		return false, fmt.Errorf("hint %d refers to a nonexistent clause", id)
+	}
+	unit := -1
+	for i, lit := range lits {
+		switch c.value(lit) {
+		case 1:
+			return false, fmt.Errorf("hint %d is satisfied", id)
+		case 0:
+			if unit >= 0 {
+				return false, fmt.Errorf("hint %d is not unit", id)
+			}
+			unit = i
+		}
+	}
+	if unit < 0 {
+		return true, nil
+	}
+	c.assign(lits[unit], id)
+	return false, nil
+}
@@ -0,0 +1,266 @@
+// Package proof checks proofs of unsatisfiability for CNF formulas.
+//
+// Two proof formats are supported, each in both its textual and binary
+// encodings (which are detected automatically):
+//
+//   - DRAT (Delete Resolution Asymmetric Tautology) proofs, as written by
+//     saturday and most other CDCL solvers. These are checked using backward
+//     checking with core-first unit propagation, in the style of drat-trim.
+//   - LRAT (Linear RAT) proofs, which add clause IDs and hints to each step
+//     so that they can be checked in a single forward pass.
+//
+// The formulas are given in the same form as the problems passed to
+// saturday.Solve (and returned by saturday.ParseDIMACS): each clause is a slice
+// of nonzero integers where negative integers indicate negated variables.
+package proof
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// A literal is a variable or its negation encoded as 2v or 2v+1.
+type literal uint32
+
+func toLit(n int) literal {
+	if n < 0 {
+		return literal(-n)<<1 | 1
+	}
+	return literal(n) << 1
+}
+
+func (l literal) int() int {
+	v := int(l >> 1)
+	if l&1 == 1 {
+		return -v
+	}
+	return v
+}
+
+func toLits(clause []int) []literal {
+	lits := make([]literal, 0, len(clause))
+	// Drop duplicate literals (but keep the order, since the first
+	// literal of a lemma is its RAT pivot).
+litLoop:
+	for _, n := range clause {
+		lit := toLit(n)
+		for _, l := range lits {
+			if l == lit {
+				continue litLoop
+			}
+		}
+		lits = append(lits, lit)
+	}
+	return lits
+}
+
+func litsString(lits []literal) string {
+	s := make([]int, len(lits))
+	for i, lit := range lits {
+		s[i] = lit.int()
+	}
+	return fmt.Sprint(s)
+}
+
+// An assignment is a partial assignment of values to variables along with the
+// trail of assigned literals in order.
+type assignment struct {
+	vals    []int8 // 1 (true), -1 (false), or 0 (unassigned) for each literal
+	reasons []int  // clause that implied each var (or -1)
+	trail   []literal
+}
+
+func (a *assignment) grow(v int) {
+	for len(a.reasons) <= v {
+		a.vals = append(a.vals, 0, 0)
+		a.reasons = append(a.reasons, -1)
+	}
+}
+
+func (a *assignment) value(lit literal) int8 { return a.vals[lit] }
+
+func (a *assignment) assign(lit literal, reason int) {
+	a.vals[lit] = 1
+	a.vals[lit^1] = -1
+	a.reasons[lit>>1] = reason
+	a.trail = append(a.trail, lit)
+}
+
+// backtrack unassigns every literal after the first n in the trail.
+func (a *assignment) backtrack(n int) {
+	for _, lit := range a.trail[n:] {
+		a.vals[lit] = 0
+		a.vals[lit^1] = 0
+	}
+	a.trail = a.trail[:n]
+}
+
+// A tokenizer reads the numbers and deletion markers of a proof in either the
+// text or binary encoding.
+type tokenizer struct {
+	r      *bufio.Reader
+	binary bool
+	step   int // current step (for error messages)
+}
+
+func newTokenizer(r io.Reader) (*tokenizer, error) {
+	br := bufio.NewReader(r)
+	// Detect the binary encoding in roughly the same way as drat-trim:
+	// the text encoding consists only of digits, letters, '-', and
+	// whitespace, but every binary step ends with a zero byte.
+	b, err := br.Peek(256)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, err
+	}
+	t := &tokenizer{r: br}
+	for _, c := range b {
+		switch {
+		case c == ' ', c == '\t', c == '\n', c == '\r', c == '-':
+		case c >= '0' && c <= '9', c >= 'A' && c <= 'z':
+		default:
+			t.binary = true
+		}
+	}
+	return t, nil
+}
+
+var errMalformed = errors.New("malformed proof")
+
+// next returns the next step marker: 'a' for an addition, 'd' for a
+// deletion, or 0 at EOF. In the text encoding, additions aren't marked, so
+// next returns 'a' unless it sees a "d".
+func (t *tokenizer) next() (byte, error) {
+	if t.binary {
+		c, err := t.r.ReadByte()
+		if err == io.EOF {
+			return 0, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		if c != 'a' && c != 'd' {
+			return 0, fmt.Errorf("step %d: bad binary step marker 0x%02x", t.step, c)
+		}
+		return c, nil
+	}
+	if err := t.skipSpace(); err != nil {
+		if err == io.EOF {
+			return 0, nil
+		}
+		return 0, err
+	}
+	c, err := t.r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	if c == 'd' {
+		return 'd', nil
+	}
+	return 'a', t.r.UnreadByte()
+}
+
+// skipSpace skips whitespace and comment lines in the text encoding.
+func (t *tokenizer) skipSpace() error {
+	for {
+		c, err := t.r.ReadByte()
+		if err != nil {
+			return err
+		}
+		switch c {
+		case ' ', '\t', '\n', '\r':
+			continue
+		case 'c':
+			if _, err := t.r.ReadString('\n'); err != nil {
+				return err
+			}
+			continue
+		}
+		return t.r.UnreadByte()
+	}
+}
+
+// int reads a (signed) integer.
+func (t *tokenizer) int() (int, error) {
+	if t.binary {
+		var u uint64
+		var shift uint
+		for {
+			c, err := t.r.ReadByte()
+			if err != nil {
+				if err == io.EOF {
+					err = fmt.Errorf("step %d: %s", t.step, io.ErrUnexpectedEOF)
+				}
+				return 0, err
+			}
+			if shift > 56 {
+				return 0, fmt.Errorf("step %d: %s: number too large", t.step, errMalformed)
+			}
+			u |= uint64(c&0x7f) << shift
+			shift += 7
+			if c&0x80 == 0 {
+				break
+			}
+		}
+		n := int(u >> 1)
+		if u&1 == 1 {
+			n = -n
+		}
+		return n, nil
+	}
+	if err := t.skipSpace(); err != nil {
+		if err == io.EOF {
+			err = fmt.Errorf("step %d: %s", t.step, io.ErrUnexpectedEOF)
+		}
+		return 0, err
+	}
+	var b []byte
+	for {
+		c, err := t.r.ReadByte()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+		if c == ' ' || c == '\t' || c == '\n' || c == '\r' {
+			break
+		}
+		b = append(b, c)
+	}
+	n, err := strconv.Atoi(string(b))
+	if err != nil {
+		return 0, fmt.Errorf("step %d: %s: bad number %q", t.step, errMalformed, b)
+	}
+	return n, nil
+}
+
+// ints reads integers up to (and not including) a terminating 0.
+func (t *tokenizer) ints() ([]int, error) {
+	var ns []int
+	for {
+		n, err := t.int()
+		if err != nil {
+			return nil, err
+		}
+		if n == 0 {
+			return ns, nil
+		}
+		ns = append(ns, n)
+	}
+}
+
+// id reads a clause ID. In the binary encoding of LRAT, IDs are encoded like
+// positive literals.
+func (t *tokenizer) id() (int, error) {
+	n, err := t.int()
+	if err != nil {
+		return 0, err
+	}
+	if n < 0 {
+		return 0, fmt.Errorf("step %d: %s: negative clause ID %d", t.step, errMalformed, n)
+	}
+	return n, nil
+}
@@ -0,0 +1,287 @@
+package proof
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// The example from the drat-trim README.
+var dratTrimCNF = [][]int{
+	{1, 2, -3},
+	{-1, -2, 3},
+	{2, 3, -4},
+	{-2, -3, 4},
+	{1, 3, 4},
+	{-1, -3, -4},
+	{-1, 2, 4},
+	{1, -2, -4},
+}
+
+// (1 ∨ 2) ∧ (¬1 ∨ 2) ∧ (1 ∨ ¬2) ∧ (¬1 ∨ ¬2)
+var allPairsCNF = [][]int{
+	{1, 2},
+	{-1, 2},
+	{1, -2},
+	{-1, -2},
+}
+
+func TestCheckDRAT(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		cnf   [][]int
+		proof string
+		ok    bool
+	}{
+		{
+			name: "drat-trim example",
+			cnf:  dratTrimCNF,
+			proof: `
+-1 0
+d -1 -2 3 0
+d -1 -3 -4 0
+d -1 2 4 0
+2 0
+0
+`,
+			ok: true,
+		},
+		{
+			name: "missing lemma",
+			cnf:  dratTrimCNF,
+			proof: `
+-1 0
+d -1 -2 3 0
+d -1 -3 -4 0
+d -1 2 4 0
+0
+`,
+			ok: false,
+		},
+		{
+			name: "non-RUP lemma",
+			cnf:  dratTrimCNF,
+			proof: `
+2 0
+0
+`,
+			ok: false,
+		},
+		{
+			name: "RUP only",
+			cnf:  allPairsCNF,
+			proof: `
+c a comment
+2 0
+0
+`,
+			ok: true,
+		},
+		{
+			name:  "no empty clause",
+			cnf:   allPairsCNF,
+			proof: "2 0\n",
+			ok:    true, // refutable by UP after the lemma
+		},
+		{
+			name: "RAT",
+			cnf:  allPairsCNF,
+			proof: `
+3 0
+-3 1 0
+d 1 2 0
+d 1 -2 0
+1 0
+0
+`,
+			ok: true,
+		},
+		{
+			name: "not RAT",
+			cnf:  allPairsCNF,
+			proof: `
+-3 1 0
+d 1 2 0
+d 1 -2 0
+1 0
+0
+`,
+			ok: false,
+		},
+		{
+			name:  "empty proof",
+			cnf:   allPairsCNF,
+			proof: "",
+			ok:    false,
+		},
+		{
+			name:  "empty clause in CNF",
+			cnf:   [][]int{{1}, {}},
+			proof: "",
+			ok:    true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, binary := range []bool{false, true} {
+				proof := strings.TrimSpace(tt.proof)
+				if binary {
+					proof = encodeBinary(t, proof, false)
+				}
+				err := CheckDRAT(tt.cnf, strings.NewReader(proof))
+				if tt.ok && err != nil {
+					t.Errorf("[binary=%t] CheckDRAT: %s", binary, err)
+				}
+				if !tt.ok && err == nil {
+					t.Errorf("[binary=%t] CheckDRAT: got nil error for invalid proof", binary)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckLRAT(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		cnf   [][]int
+		proof string
+		ok    bool
+	}{
+		{
+			name: "RUP",
+			cnf:  allPairsCNF,
+			proof: `
+5 1 0 1 3 0
+6 0 5 2 4 0
+`,
+			ok: true,
+		},
+		{
+			name: "with deletions",
+			cnf:  allPairsCNF,
+			proof: `
+5 1 0 1 3 0
+5 d 1 3 0
+6 0 5 2 4 0
+`,
+			ok: true,
+		},
+		{
+			name: "deleted hint",
+			cnf:  allPairsCNF,
+			proof: `
+5 1 0 1 3 0
+5 d 2 0
+6 0 5 2 4 0
+`,
+			ok: false,
+		},
+		{
+			name: "non-unit hint",
+			cnf:  allPairsCNF,
+			proof: `
+5 0 1 3 2 4 0
+`,
+			ok: false,
+		},
+		{
+			name: "no conflict",
+			cnf:  allPairsCNF,
+			proof: `
+5 1 0 1 0
+6 0 5 2 4 0
+`,
+			ok: false,
+		},
+		{
+			name: "no empty clause",
+			cnf:  allPairsCNF,
+			proof: `
+5 1 0 1 3 0
+`,
+			ok: false,
+		},
+		{
+			name: "reused ID",
+			cnf:  allPairsCNF,
+			proof: `
+4 1 0 1 3 0
+6 0 4 2 4 0
+`,
+			ok: false,
+		},
+		{
+			name: "RAT",
+			cnf:  append(allPairsCNF[:4:4], []int{-3, 2}),
+			proof: `
+6 3 0 -5 1 2 0
+7 2 0 1 2 0
+8 0 7 3 4 0
+`,
+			ok: true,
+		},
+		{
+			name: "missing RAT hint",
+			cnf:  append(allPairsCNF[:4:4], []int{-3, 2}),
+			proof: `
+6 3 0 -1 0
+7 2 0 1 2 0
+8 0 7 3 4 0
+`,
+			ok: false,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, binary := range []bool{false, true} {
+				proof := strings.TrimSpace(tt.proof)
+				if binary {
+					proof = encodeBinary(t, proof, true)
+				}
+				err := CheckLRAT(tt.cnf, strings.NewReader(proof))
+				if tt.ok && err != nil {
+					t.Errorf("[binary=%t] CheckLRAT: %s", binary, err)
+				}
+				if !tt.ok && err == nil {
+					t.Errorf("[binary=%t] CheckLRAT: got nil error for invalid proof", binary)
+				}
+			}
+		})
+	}
+}
+
+// encodeBinary converts a text DRAT or LRAT proof into the binary encoding.
+func encodeBinary(t *testing.T, proof string, lrat bool) string {
+	var b bytes.Buffer
+	for _, line := range strings.Split(proof, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || fields[0] == "c" {
+			continue
+		}
+		if lrat {
+			if fields[1] == "d" {
+				fields = fields[1:]
+			} else {
+				fields = append([]string{"a"}, fields...)
+			}
+		} else if fields[0] != "d" {
+			fields = append([]string{"a"}, fields...)
+		}
+		b.WriteString(fields[0])
+		for _, f := range fields[1:] {
+			n, err := strconv.Atoi(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			u := uint64(n) << 1
+			if n < 0 {
+				u = uint64(-n)<<1 | 1
+			}
+			for u >= 0x80 {
+				b.WriteByte(byte(u) | 0x80)
+				u >>= 7
+			}
+			b.WriteByte(byte(u))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,109 @@
+package saturday
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/cespare/saturday/proof"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestProof(t *testing.T) {
+	for _, tt := range loadFixtures(t, true) {
+		if tt.sat {
+			continue
+		}
+		t.Run(tt.name, func(t *testing.T) {
+			var text, binary bytes.Buffer
+			if _, _, ok := SolveWithOptions(tt.problem, &Options{Proof: &text}); ok {
+				t.Fatal("got SAT")
+			}
+			opts := &Options{Proof: &binary, ProofFormat: ProofBinary}
+			if _, _, ok := SolveWithOptions(tt.problem, opts); ok {
+				t.Fatal("got SAT")
+			}
+			steps := parseTextProof(t, text.String())
+			if diff := cmp.Diff(decodeBinaryProof(t, binary.Bytes()), steps, cmp.AllowUnexported(proofStep{})); diff != "" {
+				t.Fatalf("binary proof differs from text proof (-binary, +text):\n%s", diff)
+			}
+			if len(steps) == 0 || len(steps[len(steps)-1].lits) != 0 {
+				t.Fatal("proof doesn't end with the empty clause")
+			}
+			if err := proof.CheckDRAT(tt.problem, &binary); err != nil {
+				t.Fatalf("bad binary DRAT proof: %s", err)
+			}
+		})
+	}
+}
+
+type proofStep struct {
+	del  bool
+	lits []int
+}
+
+func parseTextProof(t *testing.T, text string) []proofStep {
+	var steps []proofStep
+	for _, line := range strings.Split(strings.TrimSpace(text), "\n") {
+		var step proofStep
+		fields := strings.Fields(line)
+		if fields[0] == "d" {
+			step.del = true
+			fields = fields[1:]
+		}
+		if fields[len(fields)-1] != "0" {
+			t.Fatalf("proof line %q doesn't end with 0", line)
+		}
+		for _, f := range fields[:len(fields)-1] {
+			v, err := strconv.Atoi(f)
+			if err != nil {
+				t.Fatalf("bad proof line %q: %s", line, err)
+			}
+			step.lits = append(step.lits, v)
+		}
+		steps = append(steps, step)
+	}
+	return steps
+}
+
+func decodeBinaryProof(t *testing.T, b []byte) []proofStep {
+	var steps []proofStep
+	for len(b) > 0 {
+		var step proofStep
+		switch b[0] {
+		case 'a':
+		case 'd':
+			step.del = true
+		default:
+			t.Fatalf("bad binary proof step type %q", b[0])
+		}
+		b = b[1:]
+		for {
+			var u uint64
+			var shift uint
+			for {
+				if len(b) == 0 {
+					t.Fatal("truncated binary proof")
+				}
+				c := b[0]
+				b = b[1:]
+				u |= uint64(c&0x7f) << shift
+				shift += 7
+				if c&0x80 == 0 {
+					break
+				}
+			}
+			if u == 0 {
+				break
+			}
+			v := int(u >> 1)
+			if u&1 == 1 {
+				v = -v
+			}
+			step.lits = append(step.lits, v)
+		}
+		steps = append(steps, step)
+	}
+	return steps
+}
@@ -0,0 +1,264 @@
+package saturday
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// A Reconstruction holds the information needed to turn a solution to a
+// problem simplified by Preprocess into a solution to the original problem.
+type Reconstruction struct {
+	// vars maps the vars of the simplified problem to the original vars:
+	// var i of the simplified problem is vars[i-1].
+	vars []int
+	// fixed gives the values of the original vars that were assigned by
+	// preprocessing (or that don't matter) as literals.
+	fixed []int
+	// eliminated lists the original vars that were removed by
+	// preprocessing. Their values are determined by stack.
+	eliminated []int
+	// stack is the reconstruction stack (see extendSolution) using the
+	// original vars. The first literal of each clause is its witness.
+	stack [][]int
+}
+
+// Preprocess simplifies problem in the same way that Solve does before it
+// starts searching (see Options.NoPreprocess) and returns the result along
+// with a Reconstruction that turns solutions to the simplified problem into
+// solutions to problem. The vars of the simplified problem are renumbered to
+// form the contiguous set [1, n], so it can be written out with WriteDIMACS
+// and given to another solver.
+//
+// If preprocessing shows that problem is unsatisfiable, the simplified
+// problem consists of a single empty clause. If preprocessing solves
+// problem, the simplified problem has no clauses.
+func Preprocess(problem [][]int) (simplified [][]int, r *Reconstruction, stats Stats) {
+	sv := newSolver(problem, nil)
+	stats = sv.stats()
+	r = new(Reconstruction)
+	switch {
+	case sv.simpleSat == assnFalse || sv.unsat:
+		return [][]int{{}}, r, stats
+	case sv.simpleSat == assnTrue:
+		r.fixed = sv.solution()
+		return [][]int{}, r, stats
+	}
+
+	// Drop the satisfied clauses and false literals given the level 0
+	// assignments from probing.
+	var kept [][]literal
+	used := make([]bool, len(sv.assignments))
+clauseLoop:
+	for _, cls := range sv.clauses {
+		var lits []literal
+		for _, lit := range cls.lits {
+			switch sv.assignments[lit>>1] {
+			case lit.assn():
+				continue clauseLoop
+			case unassigned:
+				lits = append(lits, lit)
+			}
+		}
+		for _, lit := range lits {
+			used[lit>>1] = true
+		}
+		kept = append(kept, lits)
+	}
+	newVars := make([]int, len(sv.assignments)) // var of the simplified problem (or 0)
+	for i, ok := range used {
+		if ok {
+			r.vars = append(r.vars, sv.origVars[i])
+			newVars[i] = len(r.vars)
+		}
+	}
+	simplified = make([][]int, len(kept))
+	for i, lits := range kept {
+		simplified[i] = make([]int, len(lits))
+		for j, lit := range lits {
+			n := newVars[lit>>1]
+			if lit&1 == 1 {
+				n = -n
+			}
+			simplified[i][j] = n
+		}
+	}
+
+	for _, v := range sv.sourceVars {
+		if v.eliminated {
+			r.eliminated = append(r.eliminated, v.v)
+			continue
+		}
+		assn := v.assn
+		if assn == unassigned {
+			if used[v.i] {
+				continue
+			}
+			assn = sv.assignments[v.i]
+		}
+		if assn == assnFalse {
+			r.fixed = append(r.fixed, -v.v)
+		} else {
+			// Either the var is true or its value is arbitrary.
+			r.fixed = append(r.fixed, v.v)
+		}
+	}
+	for _, e := range sv.elimStack {
+		cls := []int{sv.sourceLits([]literal{e.witness})[0]}
+		for _, lit := range e.lits {
+			if lit != e.witness {
+				cls = append(cls, sv.sourceLits([]literal{lit})[0])
+			}
+		}
+		r.stack = append(r.stack, cls)
+	}
+	return simplified, r, stats
+}
+
+// Extend turns a solution to the simplified problem returned by Preprocess
+// into a solution to the original problem. The assignment must give a value
+// to every var of the simplified problem.
+func (r *Reconstruction) Extend(assignment []int) ([]int, error) {
+	vals := make(map[int]bool)
+	for _, n := range r.fixed {
+		vals[abs(n)] = n > 0
+	}
+	assigned := make([]bool, len(r.vars))
+	for _, n := range assignment {
+		v := abs(n)
+		if v == 0 || v > len(r.vars) {
+			return nil, fmt.Errorf("assignment contains %d, but the simplified problem has %d vars", n, len(r.vars))
+		}
+		if assigned[v-1] && vals[r.vars[v-1]] != (n > 0) {
+			return nil, fmt.Errorf("assignment contains both %d and %d", v, -v)
+		}
+		assigned[v-1] = true
+		vals[r.vars[v-1]] = n > 0
+	}
+	for i, ok := range assigned {
+		if !ok {
+			return nil, fmt.Errorf("assignment has no value for var %d", i+1)
+		}
+	}
+	for _, v := range r.eliminated {
+		vals[v] = false
+	}
+stackLoop:
+	for i := len(r.stack) - 1; i >= 0; i-- {
+		cls := r.stack[i]
+		for _, n := range cls {
+			if vals[abs(n)] == (n > 0) {
+				continue stackLoop
+			}
+		}
+		vals[abs(cls[0])] = cls[0] > 0
+	}
+
+	vars := make([]int, 0, len(vals))
+	for v := range vals {
+		vars = append(vars, v)
+	}
+	sort.Ints(vars)
+	soln := make([]int, len(vars))
+	for i, v := range vars {
+		if vals[v] {
+			soln[i] = v
+		} else {
+			soln[i] = -v
+		}
+	}
+	return soln, nil
+}
+
+// WriteReconstruction writes r to w in a line-based text format similar to
+// DIMACS CNF. Each line is one of
+//
+//	m i v       var i of the simplified problem is the original var v
+//	f l         the literal l is fixed by preprocessing
+//	e v         the var v was eliminated
+//	w l ... 0   a clause on the reconstruction stack (with witness l)
+//
+// Comment lines begin with "c".
+func WriteReconstruction(w io.Writer, r *Reconstruction) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "c saturday reconstruction")
+	for i, v := range r.vars {
+		fmt.Fprintf(bw, "m %d %d\n", i+1, v)
+	}
+	for _, n := range r.fixed {
+		fmt.Fprintf(bw, "f %d\n", n)
+	}
+	for _, v := range r.eliminated {
+		fmt.Fprintf(bw, "e %d\n", v)
+	}
+	for _, cls := range r.stack {
+		bw.WriteString("w")
+		for _, n := range cls {
+			bw.WriteByte(' ')
+			bw.WriteString(strconv.Itoa(n))
+		}
+		bw.WriteString(" 0\n")
+	}
+	return bw.Flush()
+}
+
+// ParseReconstruction parses a Reconstruction written by WriteReconstruction.
+func ParseReconstruction(r io.Reader) (*Reconstruction, error) {
+	rec := new(Reconstruction)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<30)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || fields[0] == "c" {
+			continue
+		}
+		ns := make([]int, len(fields)-1)
+		for i, field := range fields[1:] {
+			n, err := strconv.Atoi(field)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: bad number %q", lineNum, field)
+			}
+			ns[i] = n
+		}
+		if fields[0] == "w" {
+			if len(ns) < 2 || ns[len(ns)-1] != 0 {
+				return nil, fmt.Errorf("line %d: malformed clause", lineNum)
+			}
+			ns = ns[:len(ns)-1]
+		}
+		if intsContain(ns, 0) {
+			return nil, fmt.Errorf("line %d: unexpected 0", lineNum)
+		}
+		switch fields[0] {
+		case "m":
+			if len(ns) != 2 {
+				return nil, fmt.Errorf("line %d: malformed var mapping", lineNum)
+			}
+			if ns[0] != len(rec.vars)+1 {
+				return nil, fmt.Errorf("line %d: got mapping for var %d; want %d", lineNum, ns[0], len(rec.vars)+1)
+			}
+			rec.vars = append(rec.vars, ns[1])
+		case "f":
+			if len(ns) != 1 {
+				return nil, fmt.Errorf("line %d: malformed fixed literal", lineNum)
+			}
+			rec.fixed = append(rec.fixed, ns[0])
+		case "e":
+			if len(ns) != 1 {
+				return nil, fmt.Errorf("line %d: malformed eliminated var", lineNum)
+			}
+			rec.eliminated = append(rec.eliminated, ns[0])
+		case "w":
+			rec.stack = append(rec.stack, ns)
+		default:
+			return nil, fmt.Errorf("line %d: unexpected line type %q", lineNum, fields[0])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return rec, nil
+}
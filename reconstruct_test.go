@@ -0,0 +1,95 @@
+package saturday
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestPreprocess(t *testing.T) {
+	tests := loadFixtures(t, false)
+	rng := rand.New(rand.NewSource(0))
+	for i := 0; i < 200; i++ {
+		problem := makeRandom3SAT(rng, 20)
+		_, _, sat := SolveWithOptions(problem, &Options{NoPreprocess: true})
+		tests = append(tests, fixtureTest{"random", problem, sat})
+	}
+	for _, tt := range tests {
+		simplified, r, _ := Preprocess(tt.problem)
+		// The simplified problem must use contiguous vars.
+		var b strings.Builder
+		if err := WriteDIMACS(&b, simplified); err != nil {
+			t.Fatalf("%s: error writing simplified problem: %s", tt.name, err)
+		}
+		soln, _, sat := SolveWithOptions(simplified, &Options{NoPreprocess: true})
+		if sat != tt.sat {
+			t.Fatalf("%s: got sat=%t for simplified problem; want %t", tt.name, sat, tt.sat)
+		}
+		if !sat {
+			continue
+		}
+
+		var rb strings.Builder
+		if err := WriteReconstruction(&rb, r); err != nil {
+			t.Fatal(err)
+		}
+		r1, err := ParseReconstruction(strings.NewReader(rb.String()))
+		if err != nil {
+			t.Fatalf("%s: error parsing reconstruction: %s", tt.name, err)
+		}
+		if diff := cmp.Diff(r1, r, cmp.AllowUnexported(Reconstruction{}), cmpopts.EquateEmpty()); diff != "" {
+			t.Fatalf("%s: reconstruction round trip (-got, +want):\n%s", tt.name, diff)
+		}
+
+		soln, err = r1.Extend(soln)
+		if err != nil {
+			t.Fatalf("%s: Extend: %s", tt.name, err)
+		}
+		if err := Verify(tt.problem, soln); err != nil {
+			t.Fatalf("%s: extended solution is invalid: %s", tt.name, err)
+		}
+		want, _, _ := Solve(tt.problem)
+		if len(soln) != len(want) {
+			t.Fatalf("%s: extended solution has %d vars; want %d", tt.name, len(soln), len(want))
+		}
+	}
+}
+
+func TestExtendErrors(t *testing.T) {
+	r := &Reconstruction{vars: []int{1, 2, 3}}
+	for _, tt := range []struct {
+		assignment []int
+		want       string
+	}{
+		{[]int{1, 2, 3, 4}, "assignment contains 4, but the simplified problem has 3 vars"},
+		{[]int{1, -2, 3, 2}, "assignment contains both 2 and -2"},
+		{[]int{1, -3}, "assignment has no value for var 2"},
+	} {
+		_, err := r.Extend(tt.assignment)
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("Extend(%v): got error %v; want %q", tt.assignment, err, tt.want)
+		}
+	}
+}
+
+func TestParseReconstructionErrors(t *testing.T) {
+	for _, tt := range []struct {
+		text string
+		want string
+	}{
+		{"m 1 3\nm 3 4\n", "line 2: got mapping for var 3; want 2"},
+		{"f 1 2\n", "line 1: malformed fixed literal"},
+		{"e 0\n", "line 1: unexpected 0"},
+		{"w 1 2\n", "line 1: malformed clause"},
+		{"w 1 0 2 0\n", "line 1: unexpected 0"},
+		{"x 1\n", `line 1: unexpected line type "x"`},
+	} {
+		_, err := ParseReconstruction(strings.NewReader(tt.text))
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("ParseReconstruction(%q): got error %v; want %q", tt.text, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package saturday
+
+// A RestartPolicy decides when the solver should restart: that is, undo all of
+// its decisions and begin the search again from level 0. Restarts keep the
+// learned clauses, var activities, and saved phases, so the solver doesn't
+// lose its progress but can escape from parts of the search space where early
+// decisions were poor.
+//
+// A RestartPolicy is generally stateful, so it must not be shared between
+// concurrent calls to the solver.
+type RestartPolicy interface {
+	// Conflict is called after each conflict with the literal block
+	// distance (LBD) of the learned clause: the number of distinct decision
+	// levels among its literals. It reports whether the solver should
+	// restart now.
+	Conflict(lbd int) bool
+}
+
+// NoRestarts returns a RestartPolicy that never restarts.
+func NoRestarts() RestartPolicy { return noRestarts{} }
+
+type noRestarts struct{}
+
+func (noRestarts) Conflict(int) bool { return false }
+
+// LubyRestarts returns a RestartPolicy that restarts after a number of
+// conflicts given by the Luby sequence (1, 1, 2, 1, 1, 2, 4, 1, ...)
+// multiplied by unit.
+func LubyRestarts(unit int) RestartPolicy {
+	if unit <= 0 {
+		panic("LubyRestarts: unit must be positive")
+	}
+	p := &lubyRestarts{unit: int64(unit)}
+	p.limit = p.unit
+	return p
+}
+
+type lubyRestarts struct {
+	unit      int64
+	i         int   // number of restarts so far
+	conflicts int64 // conflicts since the last restart
+	limit     int64
+}
+
+func (p *lubyRestarts) Conflict(int) bool {
+	p.conflicts++
+	if p.conflicts < p.limit {
+		return false
+	}
+	p.i++
+	p.conflicts = 0
+	p.limit = p.unit * luby(p.i)
+	return true
+}
+
+// luby returns the ith element (starting at 0) of the Luby sequence.
+func luby(i int) int64 {
+	// Find the finite subsequence that contains index i
+	// and the size of that subsequence.
+	size, seq := 1, 0
+	for size < i+1 {
+		seq++
+		size = 2*size + 1
+	}
+	for size-1 != i {
+		size = (size - 1) >> 1
+		seq--
+		i = i % size
+	}
+	return 1 << uint(seq)
+}
+
+// GlucoseRestarts returns a RestartPolicy that restarts dynamically based on
+// the quality of recently learned clauses, as in the Glucose solver. The
+// solver restarts when the average LBD over the last window conflicts,
+// multiplied by k, exceeds the average LBD of all learned clauses: that is,
+// when recent learned clauses are unusually poor.
+//
+// Glucose uses a window of 50 and a k of 0.8.
+func GlucoseRestarts(window int, k float64) RestartPolicy {
+	if window <= 0 {
+		panic("GlucoseRestarts: window must be positive")
+	}
+	return &glucoseRestarts{
+		recent: make([]int, window),
+		k:      k,
+	}
+}
+
+type glucoseRestarts struct {
+	k float64
+
+	// recent is a ring buffer of the most recent LBDs (since the last
+	// restart) and recentSum is the sum of its first n elements.
+	recent    []int
+	next      int
+	n         int
+	recentSum int64
+
+	totalSum int64
+	total    int64
+}
+
+func (p *glucoseRestarts) Conflict(lbd int) bool {
+	p.totalSum += int64(lbd)
+	p.total++
+	if p.n == len(p.recent) {
+		p.recentSum -= int64(p.recent[p.next])
+	} else {
+		p.n++
+	}
+	p.recent[p.next] = lbd
+	p.recentSum += int64(lbd)
+	p.next = (p.next + 1) % len(p.recent)
+	if p.n < len(p.recent) {
+		return false
+	}
+	recentAvg := float64(p.recentSum) / float64(p.n)
+	totalAvg := float64(p.totalSum) / float64(p.total)
+	if recentAvg*p.k <= totalAvg {
+		return false
+	}
+	p.n = 0
+	p.next = 0
+	p.recentSum = 0
+	return true
+}
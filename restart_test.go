@@ -0,0 +1,56 @@
+package saturday
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLuby(t *testing.T) {
+	var got []int64
+	for i := 0; i < 15; i++ {
+		got = append(got, luby(i))
+	}
+	want := []int64{1, 1, 2, 1, 1, 2, 4, 1, 1, 2, 1, 1, 2, 4, 8}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Fatalf("luby (-got, +want):\n%s", diff)
+	}
+}
+
+func TestRestartPolicies(t *testing.T) {
+	fixtures := loadFixtures(t, true)
+	for _, tt := range []struct {
+		name   string
+		policy func() RestartPolicy
+	}{
+		{"none", NoRestarts},
+		{"luby(1)", func() RestartPolicy { return LubyRestarts(1) }},
+		{"luby(100)", func() RestartPolicy { return LubyRestarts(100) }},
+		{"glucose", func() RestartPolicy { return GlucoseRestarts(50, 0.8) }},
+		{"glucose(5)", func() RestartPolicy { return GlucoseRestarts(5, 0.8) }},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, ft := range fixtures {
+				opts := &Options{Restart: tt.policy()}
+				if ft.sat {
+					t.Run(ft.name, func(t *testing.T) {
+						testFixtureSat(t, ft.problem, opts)
+					})
+				} else {
+					t.Run(ft.name, func(t *testing.T) {
+						testFixtureUnsat(t, ft.problem, opts)
+					})
+				}
+			}
+		})
+	}
+}
+
+func ExampleGlucoseRestarts() {
+	problem := [][]int{{1, 2}, {-1, 2}, {1, -2}, {-1, -2, 3}}
+	opts := &Options{Restart: GlucoseRestarts(50, 0.8)}
+	solution, _, ok := SolveWithOptions(problem, opts)
+	fmt.Println(ok, solution)
+	// Output: true [1 2 3]
+}
@@ -1,12 +1,18 @@
-// Package saturday implements a SAT solver using the Davis-Putnam backtracking
-// algorithm plus a few optimizations as described in the 2001 paper
-// Chaff: Engineering an Efficient SAT Solver.
+// Package saturday implements a conflict-driven clause learning (CDCL) SAT
+// solver based on the Davis-Putnam backtracking algorithm plus a few
+// optimizations as described in the 2001 paper Chaff: Engineering an Efficient
+// SAT Solver.
 package saturday
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"math"
+	"math/rand"
 	"sort"
 	"strings"
+	"time"
 )
 
 type solver struct {
@@ -25,28 +31,136 @@ type solver struct {
 	// simplified is the minimized problem input that doesn't include the
 	// vars already assigned in sourceVars.
 	simplified [][]int
+	// simplifiedIDs holds the derivation ID of each simplified clause (if
+	// trackCore is set; see derivs).
+	simplifiedIDs []int
+
+	// Unless noPreprocess is set, the simplified problem is further
+	// reduced by preprocess. The clauses it removes are saved in elimStack
+	// so that solution can extend the solver's assignment to the vars
+	// that were eliminated.
+	noPreprocess    bool
+	elimStack       []elimClause
+	numEliminated   int64
+	numSubsumed     int64 // including learned clauses (see subsumeLearned)
+	numStrengthened int64
+	numSubstituted  int64
+	numProbed       int64
+	numPure         int64
+	numBlocked      int64
 
 	// Everything below is the internal solver state for the vars that can't
 	// be trivially assigned based on the input.
 
-	origVars []int // mapping of internal var back to source var
+	origVars []int       // mapping of internal var back to source var
+	varIndex map[int]int // mapping of source var to internal var
+
+	// unsat is set once the clauses are found to be unsatisfiable
+	// regardless of any decisions.
+	unsat bool
 
 	assignments []assnVal
+	levels      []int   // decision level at which each var was assigned
+	reasons     []int   // clause that implied each var (or -1 for decisions)
 	watches     [][]int // watch literals (one for each literal; len is 2*len(assignments))
 
-	unassigned      []int // unassigned vars (indexes into assignments; unordered)
-	unassignedIndex []int // index of each var in unassigned (or -1)
+	// The decision heuristic is a version of VSIDS (Variable State
+	// Independent Decaying Sum) as described in the Chaff paper, using the
+	// exponential bumping scheme from MiniSat. Vars involved in conflicts
+	// have their activity bumped by varInc, and varInc grows after every
+	// conflict so that recent conflicts count for more than old ones.
+	activity []float64
+	varInc   float64
+	order    *varHeap // contains all unassigned vars (and possibly some assigned ones)
+
+	// phases holds the last value assigned to each var (or unassigned if
+	// the var has never been assigned). Decisions reuse the saved phase;
+	// for vars without one, the polarity option decides.
+	phases   []assnVal
+	polarity Polarity
+	rng      *rand.Rand // for PolarityRandom
+
+	restart        RestartPolicy
+	restartPending bool // set when the policy asks for a restart
+
+	levelStamps []int64 // scratch space for computing LBD (one for each level)
+	stamp       int64
+
+	// The learned clause database is periodically reduced to keep it from
+	// growing without bound. Clause activity works the same way as var
+	// activity (see varInc).
+	claInc      float64
+	nextReduce  int64 // reduce the clause database at this many conflicts
+	reduceInc   int64 // how much to increase nextReduce after each reduction
+	clauseRemap []int // scratch space for reduceDB
+
+	// The search gives up (see interrupted) once done is closed or once
+	// numConflicts or numDecisions reaches its limit. The limits are set
+	// at the start of each call to solve using maxConflicts and
+	// maxDecisions (where 0 means no limit).
+	done          <-chan struct{}
+	maxConflicts  int64
+	maxDecisions  int64
+	conflictLimit int64
+	decisionLimit int64
+
+	// elapsed is the time spent in previous calls to solve and start is
+	// the time that the current call started (or zero if solve isn't
+	// running).
+	elapsed time.Duration
+	start   time.Time
+
+	progress         func(Stats)
+	progressInterval time.Duration
+	nextProgress     time.Time
+
+	trace io.Writer // if non-nil, search events are logged here (see tracef)
+
+	proof    *proofWriter // if non-nil, all learned clauses are logged here
+	proofBuf []int
+
+	// If trackCore is set (see UnsatCore), derivs records how every clause
+	// was derived: derivs[id] lists the IDs of the clauses that clause id
+	// was derived from, or is nil if clause id is an input clause (in
+	// which case id is its index in the problem). unitIDs holds the ID of
+	// the clause that gives each var's value if it is assigned at level 0
+	// (or -1 if that hasn't been computed yet). When the problem is found to
+	// be unsatisfiable, coreIDs is set to the IDs of the conflicting
+	// clauses.
+	trackCore bool
+	derivs    [][]int
+	unitIDs   []int
+	coreIDs   []int
+	ants      []int // antecedents of the clause being learned
+
+	// assumptions are literals that are assigned as the first decisions
+	// (see Solver.SolveAssuming). If they lead to a conflict, failed is set
+	// to a subset of the assumptions that can't all be true.
+	assumptions []literal
+	failed      []literal
 
 	decisions    []decision // assigned vars from decision
 	implications []literal  // implied literals from decisions & further implications
 	propIndex    int        // index of the first un-propagated implication
 
-	clauses []clause
+	clauses  []clause
+	conflict int // index of the conflicting clause found by bcp
 
-	bcpBuf []literal
+	bcpBuf   []literal
+	addBuf   []literal // scratch space for addClause
+	seen     []bool    // scratch space for conflict analysis (one for each var)
+	learnBuf []literal
+	toClear  []literal // scratch space for minimize
+	minStack []literal // scratch space for minimize
 
 	numDecisions    int64
 	numImplications int64
+	numConflicts    int64
+	numLearned      int64
+	numMinimized    int64 // literals removed from learned clauses by minimize
+	numRestarts     int64
+	numReductions   int64
+	numDeleted      int64
 }
 
 type sourceVar struct {
@@ -55,81 +169,241 @@ type sourceVar struct {
 	// If assn is assnTrue or assnFalse, it means we directly assigned a
 	// unit clause from the input and this source var does not appear in the
 	// solver's database.
-	v    int
-	assn assnVal
-	i    int
+	// If eliminated is set, the var was removed by preprocessing and its
+	// value is determined by extendSolution.
+	v          int
+	assn       assnVal
+	i          int
+	eliminated bool
 }
 
 type clause struct {
 	// The watch literals are the first two in the clause.
-	lits []literal
+	// If the clause is the reason for an implication, the implied literal
+	// is lits[0].
+	lits    []literal
+	learned bool
+	id      int // derivation ID (only used if trackCore is set)
+
+	// For learned clauses, lbd is the literal block distance and activity
+	// measures how often the clause has been used in conflict analysis.
+	// These are used to decide which clauses to delete.
+	lbd      int
+	activity float64
 }
 
-const verbose = false
+func newSolver(problem [][]int, opts *Options) *solver {
+	sv := new(solver)
+	sv.init(opts)
+	sv.load(problem)
+	return sv
+}
 
-func newSolver(problem [][]int) *solver {
-	sv := simplify(problem)
+// load simplifies problem and adds the result to sv, which must be empty.
+func (sv *solver) load(problem [][]int) {
+	sv.simplify(problem)
+	// Preprocessing doesn't keep track of how the clauses it produces
+	// are derived, so it's skipped when we need an unsat core.
+	if sv.simpleSat == unassigned && !sv.noPreprocess && !sv.trackCore {
+		sv.preprocess()
+	}
 	if sv.simpleSat != unassigned {
-		return sv
+		return
 	}
-	vars := make(map[int]int) // not including vars assigned in simplify
+	// Number the internal vars in the same order as the source vars.
+	var vars []int // not including vars assigned in simplify
+	seen := make(map[int]struct{})
 	for _, cls := range sv.simplified {
 		for _, v := range cls {
 			v = abs(v)
-			if _, ok := vars[v]; !ok {
-				sv.origVars = append(sv.origVars, v)
-				vars[v] = 0
+			if _, ok := seen[v]; !ok {
+				vars = append(vars, v)
+				seen[v] = struct{}{}
 			}
 		}
 	}
-	sort.Ints(sv.origVars)
-	for i, v := range sv.origVars {
-		vars[v] = i
+	sort.Ints(vars)
+	for _, v := range vars {
+		sv.newVar(v)
 	}
 	for i, v := range sv.sourceVars {
-		if v.assn == unassigned {
-			sv.sourceVars[i].i = vars[v.v]
+		if v.assn != unassigned || v.eliminated {
+			continue
+		}
+		if j, ok := sv.varIndex[v.v]; ok {
+			sv.sourceVars[i].i = j
+		} else {
+			// The var doesn't appear in any remaining clause,
+			// so its value is arbitrary.
+			sv.sourceVars[i].assn = assnTrue
 		}
 	}
-	sv.unassigned = make([]int, len(sv.origVars))
-	sv.unassignedIndex = make([]int, len(sv.origVars))
-	for i := range sv.unassigned {
-		sv.unassigned[i] = len(sv.origVars) - i - 1
-		sv.unassignedIndex[i] = len(sv.origVars) - i - 1
-	}
-	sv.assignments = make([]assnVal, len(sv.origVars))
-	sv.watches = make([][]int, len(sv.origVars)*2)
-	sv.clauses = make([]clause, len(sv.simplified))
 	for i, cls := range sv.simplified {
-		for j, v := range cls {
-			neg := false
-			if v < 0 {
-				neg = true
-				v = -v
-			}
-			lit := literal(vars[v]) << 1
-			if neg {
-				lit ^= 1
-			}
-			sv.clauses[i].lits = append(sv.clauses[i].lits, lit)
-			if j < 2 {
-				sv.watches[lit] = append(sv.watches[lit], i)
+		var id int
+		if sv.trackCore {
+			id = sv.simplifiedIDs[i]
+		}
+		sv.addClause(cls, id)
+	}
+	if !sv.noPreprocess && !sv.trackCore {
+		sv.probe()
+	}
+	if sv.polarity == PolarityJeroslowWang {
+		sv.initJeroslowWang()
+	}
+}
+
+// init sets up an empty solver (with no vars or clauses) according to opts.
+func (sv *solver) init(opts *Options) {
+	if opts == nil {
+		opts = new(Options)
+	}
+	sv.varIndex = make(map[int]int)
+	sv.varInc = 1
+	sv.order = newVarHeap(nil)
+	sv.polarity = opts.Polarity
+	if sv.polarity == PolarityRandom {
+		sv.rng = rand.New(rand.NewSource(opts.Seed))
+	}
+	sv.restart = opts.Restart
+	if sv.restart == nil {
+		sv.restart = LubyRestarts(100)
+	}
+	sv.levelStamps = make([]int64, 1)
+	sv.claInc = 1
+	sv.nextReduce = firstReduce
+	sv.reduceInc = reduceInc
+	sv.noPreprocess = opts.NoPreprocess
+	sv.maxConflicts = opts.MaxConflicts
+	sv.maxDecisions = opts.MaxDecisions
+	sv.trace = opts.Trace
+	sv.progress = opts.Progress
+	sv.progressInterval = opts.ProgressInterval
+	if sv.progressInterval <= 0 {
+		sv.progressInterval = defaultProgressInterval
+	}
+	if opts.Proof != nil {
+		sv.proof = newProofWriter(opts.Proof, opts.ProofFormat)
+	}
+}
+
+// newVar adds a new internal var corresponding to the source var v and
+// returns its index.
+func (sv *solver) newVar(v int) int {
+	i := len(sv.origVars)
+	sv.origVars = append(sv.origVars, v)
+	sv.varIndex[v] = i
+	sv.assignments = append(sv.assignments, unassigned)
+	sv.levels = append(sv.levels, 0)
+	sv.reasons = append(sv.reasons, -1)
+	sv.seen = append(sv.seen, false)
+	sv.phases = append(sv.phases, unassigned)
+	sv.activity = append(sv.activity, 0)
+	sv.order.grow(sv.activity)
+	sv.levelStamps = append(sv.levelStamps, 0)
+	sv.watches = append(sv.watches, nil, nil)
+	if sv.trackCore {
+		sv.unitIDs = append(sv.unitIDs, -1)
+	}
+	return i
+}
+
+// addClause adds a clause (given in terms of source vars) to the clause
+// database, adding new vars as needed. The solver must be at decision level 0.
+// The id is the clause's derivation ID (see derivs); it is ignored unless
+// trackCore is set.
+//
+// Since level 0 assignments are permanent, addClause leaves out literals that
+// are false at level 0 and skips the clause entirely if it is satisfied. A
+// resulting unit clause is assigned directly (to be propagated by the next
+// call to solve) and an empty clause makes the problem unsatisfiable.
+func (sv *solver) addClause(cls []int, id int) {
+	lits := sv.addBuf[:0]
+	for _, v := range cls {
+		neg := v < 0
+		if neg {
+			v = -v
+		}
+		i, ok := sv.varIndex[v]
+		if !ok {
+			i = sv.newVar(v)
+		}
+		lit := literal(i) << 1
+		if neg {
+			lit |= 1
+		}
+		lits = append(lits, lit)
+	}
+	sv.addBuf = lits
+	// Sorting puts duplicate literals and complementary pairs next to each
+	// other.
+	sort.Slice(lits, func(i, j int) bool { return lits[i] < lits[j] })
+	ants := append(sv.ants[:0], id)
+	j := 0
+	for i, lit := range lits {
+		if i > 0 && lit == lits[i-1] {
+			continue
+		}
+		if i > 0 && lit == lits[i-1]^1 {
+			return // tautology
+		}
+		switch sv.assignments[lit>>1] {
+		case lit.assn():
+			return // already satisfied
+		case unassigned:
+			lits[j] = lit
+			j++
+		default:
+			if sv.trackCore {
+				ants = append(ants, sv.unitID(int(lit>>1)))
 			}
 		}
 	}
-	return sv
+	sv.ants = ants
+	if len(ants) > 1 {
+		id = sv.derive(ants)
+	}
+	lits = lits[:j]
+	switch len(lits) {
+	case 0:
+		sv.unsat = true
+		sv.proofAdd(nil)
+		if sv.trackCore {
+			sv.coreIDs = []int{id}
+		}
+	case 1:
+		sv.assign(lits[0], -1)
+		if sv.trackCore {
+			sv.unitIDs[lits[0]>>1] = id
+		}
+	default:
+		clauseIdx := len(sv.clauses)
+		sv.clauses = append(sv.clauses, clause{
+			lits: append([]literal(nil), lits...),
+			id:   id,
+		})
+		sv.watches[lits[0]] = append(sv.watches[lits[0]], clauseIdx)
+		sv.watches[lits[1]] = append(sv.watches[lits[1]], clauseIdx)
+	}
 }
 
 // simplify does a round of trivial simplifications on problem by looking for
 // empty and unit clauses, assigning these, and then iterating until a fixpoint
 // is located.
 //
-// The result is returned in the form of a solver sv with only sv.sourceVars and
-// sv.simplified set (as well as sv.simpleSat, if the problem is trivially
-// sat/unsat).
-func simplify(problem [][]int) *solver {
-	var sv solver
+// The result is stored in sv.sourceVars and sv.simplified (as well as
+// sv.simpleSat, if the problem is trivially sat/unsat). If sv.trackCore is
+// set, simplify also records how each simplified clause was derived from the
+// problem clauses (see derivs).
+func (sv *solver) simplify(problem [][]int) {
+	trackCore := sv.trackCore
 	vars := make(map[int]assnVal)
+	var unitIDs map[int]int // derivation ID for each assigned var
+	if trackCore {
+		sv.derivs = make([][]int, len(problem))
+		sv.simplifiedIDs = make([]int, len(problem))
+		unitIDs = make(map[int]int)
+	}
 	sv.simplified = make([][]int, len(problem))
 	for i, cls := range problem {
 		seen := make(map[int]struct{})
@@ -147,6 +421,9 @@ func simplify(problem [][]int) *solver {
 			vars[abs(v)] = unassigned
 		}
 		sv.simplified[i] = clause1
+		if trackCore {
+			sv.simplifiedIDs[i] = i
+		}
 	}
 	changed := true
 	for changed {
@@ -163,13 +440,20 @@ func simplify(problem [][]int) *solver {
 		changed = false
 		var i int
 	clauseLoop:
-		for _, cls := range sv.simplified {
+		for k, cls := range sv.simplified {
+			var id int
+			if trackCore {
+				id = sv.simplifiedIDs[k]
+			}
 			if len(cls) == 0 {
-				if verbose {
-					fmt.Println("simplify: unsat (empty clause)")
+				if sv.trace != nil {
+					sv.tracef("simplify-unsat reason=empty-clause")
 				}
 				sv.simpleSat = assnFalse
-				return &sv
+				if trackCore {
+					sv.coreIDs = []int{id}
+				}
+				return
 			}
 			if len(cls) == 1 {
 				v := cls[0]
@@ -179,20 +463,27 @@ func simplify(problem [][]int) *solver {
 					v = -v
 				}
 				if vars[v] != unassigned && vars[v] != assn {
-					if verbose {
-						fmt.Printf("simplify: unsat (contradiction on %d)\n", v)
+					if sv.trace != nil {
+						sv.tracef("simplify-unsat reason=contradiction var=%d", v)
 					}
 					sv.simpleSat = assnFalse
-					return &sv
+					if trackCore {
+						sv.coreIDs = []int{id, unitIDs[v]}
+					}
+					return
 				}
-				if verbose {
-					fmt.Printf("simplify: assigning %d->%s\n", v, assn)
+				if sv.trace != nil {
+					sv.tracef("simplify-assign var=%d value=%s", v, assn)
 				}
 				vars[v] = assn
+				if trackCore {
+					unitIDs[v] = id
+				}
 				changed = true
 				continue clauseLoop
 			}
 			var j int
+			var ants []int // IDs of the unit clauses for dropped literals
 			for _, v := range cls {
 				assn := vars[abs(v)]
 				if assn == unassigned {
@@ -206,11 +497,23 @@ func simplify(problem [][]int) *solver {
 					continue clauseLoop
 				}
 				// Literal is false and can be dropped.
+				if trackCore {
+					ants = append(ants, unitIDs[abs(v)])
+				}
 			}
 			sv.simplified[i] = cls[:j]
+			if trackCore {
+				if len(ants) > 0 {
+					id = sv.derive(append([]int{id}, ants...))
+				}
+				sv.simplifiedIDs[i] = id
+			}
 			i++
 		}
 		sv.simplified = sv.simplified[:i]
+		if trackCore {
+			sv.simplifiedIDs = sv.simplifiedIDs[:i]
+		}
 	}
 	sv.sourceVars = make([]sourceVar, 0, len(vars))
 	for v, assn := range vars {
@@ -219,7 +522,6 @@ func simplify(problem [][]int) *solver {
 	sort.Slice(sv.sourceVars, func(i, j int) bool {
 		return sv.sourceVars[i].v < sv.sourceVars[j].v
 	})
-	return &sv
 }
 
 func abs(n int) int {
@@ -236,29 +538,87 @@ func abs(n int) int {
 // an integer and negative integers indicate negated variables. The set of
 // variables must form a contiguous set [1, n].
 //
-// The stats that are given back are purely informational. The set of stats and
-// their types may change at any time.
-func Solve(problem [][]int) (assignment []int, stats map[string]interface{}, sat bool) {
-	sv := newSolver(problem)
-	ok := sv.solve()
+// The stats that are given back are purely informational (see Stats).
+func Solve(problem [][]int) (assignment []int, stats Stats, sat bool) {
+	return SolveWithOptions(problem, nil)
+}
+
+// SolveWithOptions is like Solve but configures the solver using opts.
+//
+// If opts.Proof is set, SolveWithOptions writes a proof to it but doesn't
+// report write errors. Callers should check for errors themselves (for
+// instance, by writing to a bufio.Writer and checking the error returned by
+// Flush).
+//
+// If opts sets a conflict or decision budget, SolveWithOptions returns
+// sat=false both when the problem is unsatisfiable and when the budget runs
+// out. Use SolveContext to tell these apart.
+func SolveWithOptions(problem [][]int, opts *Options) (assignment []int, stats Stats, sat bool) {
+	assignment, stats, result := SolveContext(context.Background(), problem, opts)
+	return assignment, stats, result == Satisfiable
+}
+
+// A Result is the outcome of solving a problem.
+type Result uint8
 
-	stats = map[string]interface{}{
-		"solved by simplification": sv.simpleSat != unassigned,
-		"num decisions":            sv.numDecisions,
-		"num implications":         sv.numImplications,
+const (
+	// Unknown means that the solver gave up before finding out whether
+	// the problem is satisfiable.
+	Unknown Result = iota
+	Satisfiable
+	Unsatisfiable
+)
+
+func (r Result) String() string {
+	switch r {
+	case Unknown:
+		return "UNKNOWN"
+	case Satisfiable:
+		return "SAT"
+	case Unsatisfiable:
+		return "UNSAT"
+	default:
+		return "invalid"
 	}
+}
 
-	if !ok {
-		return nil, stats, false
+// SolveContext is like SolveWithOptions but gives up early, returning
+// Unknown, if ctx is canceled (or its deadline passes) or if the solver
+// exhausts the budget set by opts.MaxConflicts or opts.MaxDecisions. The
+// solver checks for cancellation after every conflict and before every
+// decision, so SolveContext returns promptly once ctx is done.
+func SolveContext(ctx context.Context, problem [][]int, opts *Options) (assignment []int, stats Stats, result Result) {
+	start := time.Now()
+	sv := newSolver(problem, opts)
+	sv.elapsed = time.Since(start) // count the time spent simplifying
+	sv.done = ctx.Done()
+	result = sv.solve()
+	if sv.proof != nil {
+		sv.proof.flush()
 	}
+	stats = sv.stats()
+	if result != Satisfiable {
+		return nil, stats, result
+	}
+	return sv.solution(), stats, result
+}
 
-	soln := make([]int, len(sv.sourceVars))
+// solution gives the satisfying assignment found by solve in terms of the
+// source vars.
+func (sv *solver) solution() []int {
+	vals := make([]assnVal, len(sv.sourceVars))
 	for i, v := range sv.sourceVars {
-		assn := v.assn
-		if assn == unassigned {
-			assn = sv.assignments[v.i]
+		vals[i] = v.assn
+		if vals[i] == unassigned && !v.eliminated {
+			vals[i] = sv.assignments[v.i]
 		}
-		switch assn {
+	}
+	if len(sv.elimStack) > 0 {
+		sv.extendSolution(vals)
+	}
+	soln := make([]int, len(sv.sourceVars))
+	for i, v := range sv.sourceVars {
+		switch vals[i] {
 		case assnFalse:
 			soln[i] = -v.v
 		case assnTrue:
@@ -267,7 +627,7 @@ func Solve(problem [][]int) (assignment []int, stats map[string]interface{}, sat
 			panic("incomplete solution")
 		}
 	}
-	return soln, stats, true
+	return soln
 }
 
 // A literal represents an instance of a variable or its negation in a clause.
@@ -305,47 +665,159 @@ func (a assnVal) String() string {
 
 type decision struct {
 	implicationIdx int
-	v              int
+	v              int // or -1 for an empty level (see solve)
 }
 
-func (sv *solver) solve() bool {
+func (sv *solver) solve() (result Result) {
+	if sv.trace != nil {
+		defer func() { sv.tracef("result value=%s", result) }()
+	}
 	switch sv.simpleSat {
 	case assnTrue:
-		if verbose {
-			fmt.Println("problem was found satisfiable during simplification")
-		}
-		return true
+		return Satisfiable
 	case assnFalse:
-		if verbose {
-			fmt.Println("problem was found unsatisfiable during simplification")
-		}
-		return false
+		sv.proofAdd(nil)
+		return Unsatisfiable
+	}
+	sv.failed = sv.failed[:0]
+	if sv.unsat {
+		return Unsatisfiable
+	}
+	sv.start = time.Now()
+	sv.nextProgress = sv.start.Add(sv.progressInterval)
+	defer func() {
+		sv.elapsed += time.Since(sv.start)
+		sv.start = time.Time{}
+	}()
+	sv.conflictLimit = 0
+	if sv.maxConflicts > 0 {
+		sv.conflictLimit = sv.numConflicts + sv.maxConflicts
+	}
+	sv.decisionLimit = 0
+	if sv.maxDecisions > 0 {
+		sv.decisionLimit = sv.numDecisions + sv.maxDecisions
 	}
 
 	for {
-		if verbose {
-			fmt.Println("solve loop")
+		for !sv.bcp() {
+			if !sv.resolveConflict() {
+				sv.unsat = true
+				sv.proofAdd(nil)
+				if sv.trackCore {
+					sv.setCore(sv.conflict)
+				}
+				return Unsatisfiable
+			}
+			sv.maybeReportProgress()
+			if sv.interrupted() {
+				return Unknown
+			}
 		}
-		v, ok := sv.popUnassigned()
-		if !ok {
-			return true
+		if sv.restartPending {
+			sv.restartPending = false
+			sv.numRestarts++
+			if sv.trace != nil {
+				sv.tracef("restart conflicts=%d", sv.numConflicts)
+			}
+			sv.backtrack(0)
 		}
-		// Decide: set a var to true.
-		sv.assignments[v] = assnTrue
-		sv.numDecisions++
-		if verbose {
-			fmt.Printf("assigning %d->true | %s\n", sv.origVars[v], sv.stateString())
+		if sv.numConflicts >= sv.nextReduce {
+			sv.nextReduce = sv.numConflicts + sv.reduceInc
+			sv.reduceInc += reduceInc
+			sv.reduceDB()
 		}
+		lit := litNone
+		// The assumptions are the first decisions (one per level).
+	assumptionLoop:
+		for len(sv.decisions) < len(sv.assumptions) {
+			p := sv.assumptions[len(sv.decisions)]
+			switch sv.assignments[p>>1] {
+			case unassigned:
+				lit = p
+				break assumptionLoop
+			case p.assn():
+				// The assumption is already implied by the
+				// previous ones; make an empty level for it so
+				// that level i still corresponds to
+				// assumption i-1.
+				sv.decisions = append(sv.decisions, decision{
+					implicationIdx: len(sv.implications),
+					v:              -1,
+				})
+			default:
+				sv.analyzeFinal(p)
+				return Unsatisfiable
+			}
+		}
+		if lit == litNone {
+			v, ok := sv.popUnassigned()
+			if !ok {
+				return Satisfiable
+			}
+			lit = sv.pickPhase(v)
+		}
+		if sv.interrupted() {
+			return Unknown
+		}
+		v := int(lit >> 1)
+		sv.assignments[v] = lit.assn()
+		sv.numDecisions++
 		sv.decisions = append(sv.decisions, decision{
 			implicationIdx: len(sv.implications),
 			v:              v,
 		})
-		sv.propIndex = len(sv.implications)
-		sv.implications = append(sv.implications, literal(v<<1))
+		sv.levels[v] = len(sv.decisions)
+		sv.reasons[v] = -1
+		sv.implications = append(sv.implications, lit)
+		if sv.trace != nil {
+			sv.tracef("decide lit=%d level=%d", sv.origLit(lit), len(sv.decisions))
+		}
+	}
+}
 
-		for !sv.bcp() {
-			if !sv.resolveConflict() {
-				return false
+// interrupted reports whether the search should give up because sv.done is
+// closed or a budget has run out.
+func (sv *solver) interrupted() bool {
+	if sv.conflictLimit > 0 && sv.numConflicts >= sv.conflictLimit {
+		return true
+	}
+	if sv.decisionLimit > 0 && sv.numDecisions >= sv.decisionLimit {
+		return true
+	}
+	select {
+	case <-sv.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// analyzeFinal is called when the assumption p is false under the current
+// assignment (at which point every decision is an assumption). It sets
+// sv.failed to the set of assumptions (including p) which together imply ¬p,
+// which means that they can't all hold at once.
+func (sv *solver) analyzeFinal(p literal) {
+	sv.failed = append(sv.failed[:0], p)
+	if sv.levels[p>>1] == 0 {
+		return
+	}
+	sv.seen[p>>1] = true
+	for i := len(sv.implications) - 1; i >= sv.decisions[0].implicationIdx; i-- {
+		lit := sv.implications[i]
+		v := lit >> 1
+		if !sv.seen[v] {
+			continue
+		}
+		sv.seen[v] = false
+		reason := sv.reasons[v]
+		if reason < 0 {
+			// A decision, so it must be an assumption.
+			sv.failed = append(sv.failed, lit)
+			continue
+		}
+		for _, q := range sv.clauses[reason].lits {
+			if q>>1 != v && sv.levels[q>>1] > 0 {
+				sv.seen[q>>1] = true
 			}
 		}
 	}
@@ -366,23 +838,13 @@ func intsContain(s []int, n int) bool {
 func (sv *solver) bcp() bool {
 	for {
 		imps := sv.implications[sv.propIndex:]
-		if verbose {
-			fmt.Printf("  bcp loop | %s\n", sv.stateString())
-		}
 		if len(imps) == 0 {
 			// No implications left to propagate.
-			if verbose {
-				fmt.Println("  no more implications")
-			}
 			return true
 		}
 		sv.propIndex = len(sv.implications)
 		for _, impliedLit := range imps {
 			neg := impliedLit ^ 1
-			if verbose {
-				fmt.Printf("  checking impl %d by visiting watches for %d\n",
-					sv.origLit(impliedLit), sv.origLit(neg))
-			}
 			watches := sv.watches[neg]
 		watchesLoop:
 			for i := 0; i < len(watches); {
@@ -426,17 +888,18 @@ func (sv *solver) bcp() bool {
 				otherWatch := cls.lits[0]
 				v := int(otherWatch >> 1)
 				if sv.assignments[v] != unassigned {
-					if verbose {
-						fmt.Printf("  conflict at clause %d\n", clauseIdx)
+					if sv.trace != nil {
+						sv.tracef("conflict level=%d clause=%s", len(sv.decisions), sv.clauseString(cls.lits))
 					}
+					sv.conflict = clauseIdx
 					return false
 				}
-				if verbose {
-					fmt.Printf("  clause %d is unit (imp: %d)\n", clauseIdx, sv.origLit(otherWatch))
-					fmt.Printf("    assigning to %s\n", otherWatch.assn())
+				if sv.trace != nil {
+					sv.tracef("propagate lit=%d level=%d reason=%s", sv.origLit(otherWatch), len(sv.decisions), sv.clauseString(cls.lits))
 				}
 				sv.assignments[v] = otherWatch.assn()
-				sv.deleteUnassigned(v)
+				sv.levels[v] = len(sv.decisions)
+				sv.reasons[v] = clauseIdx
 				sv.numImplications++
 				sv.implications = append(sv.implications, otherWatch)
 			}
@@ -444,26 +907,17 @@ func (sv *solver) bcp() bool {
 	}
 }
 
-func (sv *solver) stateString() string {
-	var b strings.Builder
-	b.WriteByte('{')
-	for i, assn := range sv.assignments {
-		var s string
-		if i > 0 {
-			s = ", "
-		}
-		fmt.Fprintf(&b, "%s%d:%c", s, sv.origVars[i], assn.String()[0])
-	}
-	b.WriteString("} watch: {")
-	for lit, watches := range sv.watches {
-		var s string
-		if lit > 0 {
-			s = ", "
-		}
-		fmt.Fprintf(&b, "%s%d->%v", s, sv.origLit(literal(lit)), watches)
-	}
-	b.WriteString("}")
-	return b.String()
+// tracef writes an event to sv.trace. Each event is a single line consisting
+// of the event name followed by key=value pairs. Callers should check that
+// sv.trace is non-nil before calling tracef to avoid formatting the arguments
+// unnecessarily.
+func (sv *solver) tracef(format string, args ...interface{}) {
+	fmt.Fprintf(sv.trace, format+"\n", args...)
+}
+
+// clauseString formats a clause in terms of the source vars for tracing.
+func (sv *solver) clauseString(lits []literal) string {
+	return strings.Replace(fmt.Sprint(sv.origLits(lits)), " ", ",", -1)
 }
 
 func (sv *solver) origLit(lit literal) int {
@@ -474,65 +928,572 @@ func (sv *solver) origLit(lit literal) int {
 	return x
 }
 
-// resolveConflict tries to fix the current conflict by flipping the most
-// recently made decision.
+// resolveConflict analyzes the current conflict and adds the resulting learned
+// clause to the clause database. Then it rolls back the trail so that the
+// search can continue. It returns false if the conflict doesn't depend on any
+// decision (that is, the problem is unsatisfiable).
 func (sv *solver) resolveConflict() bool {
-	if verbose {
-		fmt.Println("  resolveConflict")
-	}
-	di := -1
-	var d decision
-	for i := len(sv.decisions) - 1; i >= 0; i-- {
-		d = sv.decisions[i]
-		if sv.assignments[d.v] == assnTrue {
-			// d hasn't been tried both ways yet.
-			di = i
+	sv.numConflicts++
+	if len(sv.decisions) == 0 {
+		return false // not satisfiable
+	}
+	learned := sv.analyze()
+	sv.decayVars()
+	sv.decayClauses()
+	lbd := sv.lbd(learned)
+	if sv.restart.Conflict(lbd) {
+		// Finish handling this conflict (so that the learned
+		// clause is asserted) and restart before the next decision.
+		sv.restartPending = true
+	}
+	if sv.trace != nil {
+		sv.tracef("learn clause=%s lbd=%d", sv.clauseString(learned), lbd)
+	}
+	sv.proofAdd(learned)
+	var id int
+	if sv.trackCore {
+		id = sv.derive(sv.ants)
+	}
+	if len(learned) == 1 {
+		// A unit learned clause holds regardless of any decision, so
+		// we assign it directly at level 0 rather than storing it.
+		sv.backtrack(0)
+		sv.assign(learned[0], -1)
+		if sv.trace != nil {
+			sv.tracef("propagate lit=%d level=0 reason=%s", sv.origLit(learned[0]), sv.clauseString(learned))
+		}
+		if sv.trackCore {
+			sv.unitIDs[learned[0]>>1] = id
+		}
+		return true
+	}
+	clauseIdx := sv.addLearned(learned, lbd)
+	sv.clauses[clauseIdx].id = id
+	// The learned clause contains a single literal from the conflict
+	// level, so it becomes unit as soon as we undo that level. Rather
+	// than undoing one decision at a time, jump straight back to the
+	// assertion level (the highest level among the other literals): any
+	// decisions made after that point were irrelevant to the conflict.
+	cls := sv.clauses[clauseIdx]
+	sv.backtrack(sv.levels[cls.lits[1]>>1])
+	sv.assign(cls.lits[0], clauseIdx)
+	if sv.trace != nil {
+		sv.tracef("propagate lit=%d level=%d reason=%s", sv.origLit(cls.lits[0]), len(sv.decisions), sv.clauseString(cls.lits))
+	}
+	return true
+}
+
+// analyze derives a learned clause from the current conflict by resolving the
+// conflicting clause with the reasons for the implications at the current
+// decision level until only a single literal from that level remains (the
+// first unique implication point, or first UIP).
+//
+// The first literal of the returned clause is the negation of the UIP; all
+// the literals are false under the current assignment. The returned slice is
+// only valid until the next call to analyze.
+//
+// If trackCore is set, analyze also sets sv.ants to the IDs of the clauses
+// from which the learned clause is derived.
+func (sv *solver) analyze() []literal {
+	level := len(sv.decisions)
+	learned := append(sv.learnBuf[:0], litNone) // placeholder for the UIP
+	sv.ants = sv.ants[:0]
+	// pathCount is the number of seen vars at the current level that
+	// haven't been resolved on yet.
+	pathCount := 0
+	p := litNone
+	idx := len(sv.implications) - 1
+	clauseIdx := sv.conflict
+	for {
+		cls := &sv.clauses[clauseIdx]
+		if sv.trackCore {
+			sv.ants = append(sv.ants, cls.id)
+		}
+		if cls.learned {
+			sv.bumpClause(cls)
+			// Glucose-style LBD updates: a clause used in
+			// analysis may have a lower LBD now than when it was
+			// learned.
+			if cls.lbd > 2 {
+				if lbd := sv.lbd(cls.lits); lbd < cls.lbd {
+					cls.lbd = lbd
+				}
+			}
+		}
+		for _, q := range cls.lits {
+			if q == p {
+				continue
+			}
+			v := q >> 1
+			if sv.seen[v] {
+				continue
+			}
+			if sv.levels[v] == 0 {
+				// Vars assigned at level 0 are false regardless
+				// of any decision so they can be dropped.
+				if sv.trackCore {
+					sv.ants = append(sv.ants, sv.unitID(int(v)))
+				}
+				continue
+			}
+			sv.seen[v] = true
+			sv.bumpVar(int(v))
+			if sv.levels[v] == level {
+				pathCount++
+			} else {
+				learned = append(learned, q)
+			}
+		}
+		// Find the next var (in reverse trail order) to resolve on.
+		for !sv.seen[sv.implications[idx]>>1] {
+			idx--
+		}
+		p = sv.implications[idx]
+		idx--
+		sv.seen[p>>1] = false
+		pathCount--
+		if pathCount == 0 {
 			break
 		}
+		clauseIdx = sv.reasons[p>>1]
 	}
-	if di == -1 {
-		return false // not satisfiable
+	learned[0] = p ^ 1
+	learned = sv.minimize(learned)
+	sv.learnBuf = learned
+	return learned
+}
+
+// minimize shrinks a learned clause using recursive self-subsuming resolution
+// (as in MiniSat): a literal can be removed if it is implied by other literals
+// in the clause, directly or through a chain of reasons.
+//
+// When minimize is called, the seen flag is set for the vars of all the
+// literals in learned except for the first; minimize clears them.
+func (sv *solver) minimize(learned []literal) []literal {
+	sv.toClear = append(sv.toClear[:0], learned[1:]...)
+	// Removing a literal requires that every var in its implication
+	// chain is either in the clause or has its own reason. Those vars must
+	// be at a level that appears among the clause literals, which makes
+	// the abstract level check a cheap way to rule out most literals early.
+	var abstract uint32
+	for _, q := range learned[1:] {
+		abstract |= sv.abstractLevel(q >> 1)
+	}
+	j := 1
+	for _, q := range learned[1:] {
+		if sv.reasons[q>>1] < 0 || !sv.redundant(q, abstract) {
+			learned[j] = q
+			j++
+		}
 	}
-	// Flip d from true to false and roll back the invalidated implications.
-	if verbose {
-		fmt.Printf("  assigning %d->false | %s\n", sv.origVars[d.v], sv.stateString())
+	sv.numMinimized += int64(len(learned) - j)
+	for _, q := range sv.toClear {
+		sv.seen[q>>1] = false
 	}
-	for i := len(sv.implications) - 1; i > d.implicationIdx; i-- {
+	return learned[:j]
+}
+
+func (sv *solver) abstractLevel(v literal) uint32 {
+	return 1 << (uint(sv.levels[v]) & 31)
+}
+
+// redundant reports whether the literal p of a learned clause is implied by the
+// clause's other literals (that is, those vars marked as seen). Any vars found
+// to be implied along the way are also marked as seen (and recorded in
+// toClear) so that later checks can stop at them.
+func (sv *solver) redundant(p literal, abstract uint32) bool {
+	stack := append(sv.minStack[:0], p)
+	top := len(sv.toClear)
+	antsTop := len(sv.ants)
+	defer func() { sv.minStack = stack[:0] }()
+	for len(stack) > 0 {
+		q := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		cls := &sv.clauses[sv.reasons[q>>1]]
+		if sv.trackCore {
+			sv.ants = append(sv.ants, cls.id)
+		}
+		for _, lit := range cls.lits {
+			v := lit >> 1
+			if v == q>>1 || sv.seen[v] {
+				continue
+			}
+			if sv.levels[v] == 0 {
+				if sv.trackCore {
+					sv.ants = append(sv.ants, sv.unitID(int(v)))
+				}
+				continue
+			}
+			if sv.reasons[v] < 0 || sv.abstractLevel(v)&abstract == 0 {
+				// lit is a decision or can't be implied by
+				// the clause literals. Roll back the vars we
+				// marked during this check.
+				for _, lit := range sv.toClear[top:] {
+					sv.seen[lit>>1] = false
+				}
+				sv.toClear = sv.toClear[:top]
+				sv.ants = sv.ants[:antsTop]
+				return false
+			}
+			sv.seen[v] = true
+			stack = append(stack, lit)
+			sv.toClear = append(sv.toClear, lit)
+		}
+	}
+	return true
+}
+
+// lbd computes the literal block distance of a clause: the number of distinct
+// decision levels among its literals.
+func (sv *solver) lbd(lits []literal) int {
+	// There is usually at most one level per var, but assumptions that
+	// are already true get empty levels of their own (see solve), so
+	// duplicate assumptions can make more.
+	for len(sv.levelStamps) <= len(sv.decisions) {
+		sv.levelStamps = append(sv.levelStamps, 0)
+	}
+	sv.stamp++
+	n := 0
+	for _, lit := range lits {
+		level := sv.levels[lit>>1]
+		if sv.levelStamps[level] != sv.stamp {
+			sv.levelStamps[level] = sv.stamp
+			n++
+		}
+	}
+	return n
+}
+
+// addLearned adds a copy of lits, a learned clause as returned by analyze, to
+// the clause database and returns its index.
+func (sv *solver) addLearned(lits []literal, lbd int) int {
+	// Watch the UIP literal as well as the literal assigned at the
+	// highest level among the rest. These are the last two literals to
+	// become unassigned as the trail is rolled back.
+	max := 1
+	for i := 2; i < len(lits); i++ {
+		if sv.levels[lits[i]>>1] > sv.levels[lits[max]>>1] {
+			max = i
+		}
+	}
+	lits[1], lits[max] = lits[max], lits[1]
+	clauseIdx := len(sv.clauses)
+	sv.clauses = append(sv.clauses, clause{
+		lits:     append([]literal(nil), lits...),
+		learned:  true,
+		lbd:      lbd,
+		activity: sv.claInc,
+	})
+	sv.watches[lits[0]] = append(sv.watches[lits[0]], clauseIdx)
+	sv.watches[lits[1]] = append(sv.watches[lits[1]], clauseIdx)
+	sv.numLearned++
+	return clauseIdx
+}
+
+const (
+	claDecay        = 0.999
+	claRescaleLimit = 1e20
+
+	// The clause database is first reduced after firstReduce conflicts.
+	// The interval between reductions starts at reduceInc and grows by
+	// reduceInc after each reduction (as in Glucose).
+	firstReduce = 2000
+	reduceInc   = 300
+)
+
+func (sv *solver) bumpClause(cls *clause) {
+	cls.activity += sv.claInc
+	if cls.activity > claRescaleLimit {
+		for i := range sv.clauses {
+			sv.clauses[i].activity /= claRescaleLimit
+		}
+		sv.claInc /= claRescaleLimit
+	}
+}
+
+func (sv *solver) decayClauses() {
+	sv.claInc /= claDecay
+}
+
+// locked reports whether the clause at clauseIdx is the reason for a current
+// implication (and therefore can't be deleted).
+func (sv *solver) locked(clauseIdx int) bool {
+	lit := sv.clauses[clauseIdx].lits[0]
+	v := lit >> 1
+	return sv.assignments[v] == lit.assn() && sv.reasons[v] == clauseIdx
+}
+
+// reduceDB deletes the learned clauses that are subsumed by other learned
+// clauses and then about half of the rest, preferring to keep clauses with low
+// LBD and high activity. Clauses with an LBD of 2 or less ("glue clauses") and
+// clauses that are the reasons for current implications are always kept
+// (unless they are subsumed).
+func (sv *solver) reduceDB() {
+	sv.numReductions++
+	subsumed := sv.subsumeLearned()
+	isSubsumed := make([]bool, len(sv.clauses))
+	for _, i := range subsumed {
+		isSubsumed[i] = true
+	}
+	var candidates []int
+	for i, cls := range sv.clauses {
+		if cls.learned && cls.lbd > 2 && !sv.locked(i) && !isSubsumed[i] {
+			candidates = append(candidates, i)
+		}
+	}
+	// Sort the candidates from worst to best.
+	sort.Slice(candidates, func(i, j int) bool {
+		c0, c1 := &sv.clauses[candidates[i]], &sv.clauses[candidates[j]]
+		if c0.lbd != c1.lbd {
+			return c0.lbd > c1.lbd
+		}
+		return c0.activity < c1.activity
+	})
+	candidates = append(candidates[:len(candidates)/2], subsumed...)
+	if len(candidates) == 0 {
+		return
+	}
+	if sv.trace != nil {
+		sv.tracef("reduce-db deleted=%d subsumed=%d", len(candidates), len(subsumed))
+	}
+	sv.deleteClauses(candidates)
+}
+
+// subsumeLearned finds the learned clauses that are subsumed by other learned
+// clauses (and aren't locked) and returns their indexes. A clause that
+// subsumes another inherits its LBD if that is lower, since it is at least as
+// useful.
+func (sv *solver) subsumeLearned() []int {
+	var learned []int
+	occurs := make([][]int, len(sv.watches))
+	sigs := make([]uint64, len(sv.clauses))
+	for i, cls := range sv.clauses {
+		if !cls.learned {
+			continue
+		}
+		learned = append(learned, i)
+		for _, lit := range cls.lits {
+			occurs[lit] = append(occurs[lit], i)
+			sigs[i] |= 1 << (lit & 63)
+		}
+	}
+	// Shorter clauses are more likely to subsume others.
+	sort.SliceStable(learned, func(i, j int) bool {
+		return len(sv.clauses[learned[i]].lits) < len(sv.clauses[learned[j]].lits)
+	})
+	var subsumed []int
+	dead := make([]bool, len(sv.clauses))
+	for _, i := range learned {
+		if dead[i] {
+			continue
+		}
+		c := &sv.clauses[i]
+		best := c.lits[0]
+		for _, lit := range c.lits[1:] {
+			if len(occurs[lit]) < len(occurs[best]) {
+				best = lit
+			}
+		}
+	occursLoop:
+		for _, j := range occurs[best] {
+			d := &sv.clauses[j]
+			if j == i || dead[j] || len(d.lits) < len(c.lits) || sigs[i]&^sigs[j] != 0 {
+				continue
+			}
+			for _, lit := range c.lits {
+				if !literalsContain(d.lits, lit) {
+					continue occursLoop
+				}
+			}
+			if sv.locked(j) {
+				continue
+			}
+			dead[j] = true
+			subsumed = append(subsumed, j)
+			sv.numSubsumed++
+			if d.lbd < c.lbd {
+				c.lbd = d.lbd
+			}
+		}
+	}
+	return subsumed
+}
+
+func literalsContain(lits []literal, lit literal) bool {
+	for _, l := range lits {
+		if l == lit {
+			return true
+		}
+	}
+	return false
+}
+
+// deleteClauses removes the clauses at the given indexes from the clause
+// database. Since this changes the indexes of the remaining clauses, the
+// reasons are renumbered and the watch lists are rebuilt.
+func (sv *solver) deleteClauses(clauseIdxs []int) {
+	remap := sv.clauseRemap[:0]
+	for range sv.clauses {
+		remap = append(remap, 0)
+	}
+	for _, clauseIdx := range clauseIdxs {
+		remap[clauseIdx] = -1
+	}
+	var n int
+	for i, cls := range sv.clauses {
+		if remap[i] < 0 {
+			sv.numDeleted++
+			sv.proofDelete(cls.lits)
+			continue
+		}
+		remap[i] = n
+		sv.clauses[n] = cls
+		n++
+	}
+	for i := n; i < len(sv.clauses); i++ {
+		sv.clauses[i] = clause{}
+	}
+	sv.clauses = sv.clauses[:n]
+	sv.clauseRemap = remap
+
+	for _, lit := range sv.implications {
+		v := lit >> 1
+		if reason := sv.reasons[v]; reason >= 0 {
+			sv.reasons[v] = remap[reason]
+		}
+	}
+	for lit := range sv.watches {
+		sv.watches[lit] = sv.watches[lit][:0]
+	}
+	for i, cls := range sv.clauses {
+		sv.watches[cls.lits[0]] = append(sv.watches[cls.lits[0]], i)
+		sv.watches[cls.lits[1]] = append(sv.watches[cls.lits[1]], i)
+	}
+}
+
+// assign records lit as an implication at the current decision level because
+// of the given reason clause (or -1 if there is no reason clause).
+func (sv *solver) assign(lit literal, reason int) {
+	v := int(lit >> 1)
+	sv.assignments[v] = lit.assn()
+	sv.levels[v] = len(sv.decisions)
+	sv.reasons[v] = reason
+	sv.implications = append(sv.implications, lit)
+}
+
+// backtrack undoes all the assignments made above the given decision level.
+func (sv *solver) backtrack(level int) {
+	if len(sv.decisions) <= level {
+		return
+	}
+	start := sv.decisions[level].implicationIdx
+	if sv.trace != nil {
+		sv.tracef("backjump from=%d to=%d", len(sv.decisions), level)
+	}
+	for i := len(sv.implications) - 1; i >= start; i-- {
 		v := int(sv.implications[i] >> 1)
-		sv.addUnassigned(v)
+		sv.phases[v] = sv.assignments[v]
 		sv.assignments[v] = unassigned
+		if !sv.order.contains(v) {
+			sv.order.push(v)
+		}
 	}
-	sv.implications = sv.implications[:d.implicationIdx+1]
-	sv.implications[len(sv.implications)-1] ^= 1
-	sv.decisions = sv.decisions[:di+1]
-	sv.assignments[d.v] = assnFalse
-	sv.propIndex = d.implicationIdx
-	return true
+	sv.implications = sv.implications[:start]
+	sv.decisions = sv.decisions[:level]
+	sv.propIndex = start
 }
 
+func (sv *solver) origLits(lits []literal) []int {
+	s := make([]int, len(lits))
+	for i, lit := range lits {
+		s[i] = sv.origLit(lit)
+	}
+	return s
+}
+
+// popUnassigned returns the unassigned var with the highest activity.
 func (sv *solver) popUnassigned() (int, bool) {
-	if len(sv.unassigned) == 0 {
-		return 0, false
+	// Assigned vars are removed from the heap lazily.
+	for sv.order.len() > 0 {
+		v := sv.order.pop()
+		if sv.assignments[v] == unassigned {
+			return v, true
+		}
 	}
-	v := sv.unassigned[len(sv.unassigned)-1]
-	sv.unassigned = sv.unassigned[:len(sv.unassigned)-1]
-	sv.unassignedIndex[v] = -1
-	return v, true
+	return 0, false
 }
 
-func (sv *solver) deleteUnassigned(v int) {
-	i := sv.unassignedIndex[v]
-	last := sv.unassigned[len(sv.unassigned)-1]
-	sv.unassigned[i] = last
-	sv.unassigned = sv.unassigned[:len(sv.unassigned)-1]
-	sv.unassignedIndex[last] = i // last may be v
-	sv.unassignedIndex[v] = -1
+// pickPhase returns the literal to assign when making a decision on var v.
+func (sv *solver) pickPhase(v int) literal {
+	lit := literal(v << 1)
+	phase := sv.phases[v]
+	if phase == unassigned {
+		switch sv.polarity {
+		case PolarityTrue:
+			phase = assnTrue
+		case PolarityFalse:
+			phase = assnFalse
+		case PolarityRandom:
+			phase = assnVal(sv.rng.Intn(2)) + 1
+		case PolarityJeroslowWang:
+			// initJeroslowWang stored the preferred values
+			// in phases, so we only get here for vars which
+			// don't appear in any clause.
+			phase = assnTrue
+		default:
+			panic("unreached")
+		}
+	}
+	if phase == assnFalse {
+		lit |= 1
+	}
+	return lit
 }
 
-func (sv *solver) addUnassigned(v int) {
-	if sv.unassignedIndex[v] != -1 {
-		panic("assigning already-assigned var")
+// initJeroslowWang computes the Jeroslow-Wang score for each literal and seeds
+// the saved phase of each var that doesn't have one with its higher-scoring
+// value.
+func (sv *solver) initJeroslowWang() {
+	scores := make([]float64, len(sv.watches))
+	for _, cls := range sv.clauses {
+		score := math.Ldexp(1, -len(cls.lits))
+		for _, lit := range cls.lits {
+			scores[lit] += score
+		}
 	}
-	sv.unassignedIndex[v] = len(sv.unassigned)
-	sv.unassigned = append(sv.unassigned, v)
+	for v, phase := range sv.phases {
+		if phase != unassigned {
+			continue
+		}
+		pos, neg := scores[v<<1], scores[v<<1|1]
+		switch {
+		case pos > neg:
+			sv.phases[v] = assnTrue
+		case neg > pos:
+			sv.phases[v] = assnFalse
+		}
+	}
+}
+
+const (
+	varDecay        = 0.95
+	varRescaleLimit = 1e100
+)
+
+func (sv *solver) bumpVar(v int) {
+	sv.activity[v] += sv.varInc
+	if sv.activity[v] > varRescaleLimit {
+		// Scale everything down to avoid overflow.
+		for i := range sv.activity {
+			sv.activity[i] /= varRescaleLimit
+		}
+		sv.varInc /= varRescaleLimit
+	}
+	sv.order.increased(v)
+}
+
+// decayVars decays the activity of all vars. Rather than touching every var,
+// it increases the amount by which future bumps increase activity.
+func (sv *solver) decayVars() {
+	sv.varInc /= varDecay
 }
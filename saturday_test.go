@@ -1,28 +1,228 @@
 package saturday
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"math/rand"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"testing"
+
+	"github.com/cespare/saturday/proof"
+	"github.com/google/go-cmp/cmp"
 )
 
 func TestFixtures(t *testing.T) {
 	for _, tt := range loadFixtures(t, false) {
 		if tt.sat {
 			t.Run(tt.name, func(t *testing.T) {
-				testFixtureSat(t, tt.problem)
+				testFixtureSat(t, tt.problem, nil)
 			})
 		} else {
 			t.Run(tt.name, func(t *testing.T) {
-				testFixtureUnsat(t, tt.problem)
+				testFixtureUnsat(t, tt.problem, nil)
 			})
 		}
 	}
 }
 
+func TestPolarity(t *testing.T) {
+	fixtures := loadFixtures(t, true)
+	for _, polarity := range []Polarity{
+		PolarityTrue,
+		PolarityFalse,
+		PolarityRandom,
+		PolarityJeroslowWang,
+	} {
+		opts := &Options{Polarity: polarity}
+		t.Run(polarity.String(), func(t *testing.T) {
+			for _, tt := range fixtures {
+				if tt.sat {
+					t.Run(tt.name, func(t *testing.T) {
+						testFixtureSat(t, tt.problem, opts)
+					})
+				} else {
+					t.Run(tt.name, func(t *testing.T) {
+						testFixtureUnsat(t, tt.problem, opts)
+					})
+				}
+			}
+		})
+	}
+}
+
+func TestReduceDB(t *testing.T) {
+	var deleted int64
+	for _, tt := range loadFixtures(t, false) {
+		t.Run(tt.name, func(t *testing.T) {
+			sv := newSolver(tt.problem, nil)
+			// Reduce the clause database very often.
+			sv.nextReduce = 5
+			sv.reduceInc = 5
+			ok := sv.solve() == Satisfiable
+			if ok != tt.sat {
+				t.Fatalf("got sat=%t; want %t", ok, tt.sat)
+			}
+			if ok && !solutionIsValid(tt.problem, sv.solution()) {
+				t.Fatal("got invalid solution")
+			}
+			var learned int64
+			for _, cls := range sv.clauses {
+				if cls.learned {
+					learned++
+				}
+			}
+			if got, want := learned, sv.numLearned-sv.numDeleted; got != want {
+				t.Errorf("got %d learned clauses in the database; want %d", got, want)
+			}
+			deleted += sv.numDeleted
+		})
+	}
+	if deleted == 0 {
+		t.Error("no clauses were deleted")
+	}
+}
+
+func TestSubsumeLearned(t *testing.T) {
+	sv := newSolver([][]int{{1, 2, 3, 4}, {-1, -2, -3, -4}}, &Options{NoPreprocess: true})
+	lits := func(vs ...int) []literal {
+		var lits []literal
+		for _, v := range vs {
+			lit := literal(sv.varIndex[abs(v)]) << 1
+			if v < 0 {
+				lit |= 1
+			}
+			lits = append(lits, lit)
+		}
+		return lits
+	}
+	for _, cls := range []struct {
+		lits []literal
+		lbd  int
+	}{
+		{lits(1, 2, 3), 3},
+		{lits(1, 2), 5},
+		{lits(2, 1, 4), 2},
+		{lits(-1, 2, 3), 2},
+		{lits(1, 3, 4), 3},
+	} {
+		sv.addLearned(cls.lits, cls.lbd)
+	}
+	// The first and third learned clauses are subsumed by the second.
+	subsumed := sv.subsumeLearned()
+	sort.Ints(subsumed)
+	if diff := cmp.Diff(subsumed, []int{2, 4}); diff != "" {
+		t.Fatalf("subsumeLearned (-got, +want):\n%s", diff)
+	}
+	if got := sv.clauses[3].lbd; got != 2 {
+		t.Errorf("subsuming clause has LBD %d; want 2", got)
+	}
+}
+
+// TestLearnedClausesImplied checks that each learned (and minimized) clause
+// is implied by the input problem.
+func TestLearnedClausesImplied(t *testing.T) {
+	for _, tt := range loadFixtures(t, true) {
+		t.Run(tt.name, func(t *testing.T) {
+			sv := newSolver(tt.problem, nil)
+			sv.solve()
+			for _, cls := range sv.clauses {
+				if !cls.learned {
+					continue
+				}
+				learned := sv.origLits(cls.lits)
+				problem := append([][]int(nil), tt.problem...)
+				for _, v := range learned {
+					problem = append(problem, []int{-v})
+				}
+				if soln, _, ok := Solve(problem); ok {
+					t.Fatalf("learned clause %v is not implied by the problem (counterexample: %v)",
+						learned, soln)
+				}
+			}
+		})
+	}
+}
+
+func TestSolveContext(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, tt := range loadFixtures(t, false) {
+		t.Run(tt.name, func(t *testing.T) {
+			want := Unsatisfiable
+			if tt.sat {
+				want = Satisfiable
+			}
+			soln, stats, result := SolveContext(context.Background(), tt.problem, nil)
+			if result != want {
+				t.Fatalf("got %s; want %s", result, want)
+			}
+			if result == Satisfiable && !solutionIsValid(tt.problem, soln) {
+				t.Fatal("got invalid solution")
+			}
+			if stats.SolvedBySimplification {
+				return
+			}
+
+			_, stats, result = SolveContext(canceled, tt.problem, nil)
+			if result != Unknown {
+				t.Errorf("with canceled context: got %s; want UNKNOWN", result)
+			}
+			if n := stats.Decisions; n > 0 {
+				t.Errorf("with canceled context: got %d decisions", n)
+			}
+
+			const budget = 3
+			for _, opts := range []*Options{
+				{MaxConflicts: budget},
+				{MaxDecisions: budget},
+			} {
+				_, stats, result = SolveContext(context.Background(), tt.problem, opts)
+				stat, n := "conflicts", stats.Conflicts
+				if opts.MaxDecisions > 0 {
+					stat, n = "decisions", stats.Decisions
+				}
+				if n > budget {
+					t.Errorf("with %+v: got %d (%s)", opts, n, stat)
+				}
+				if result == Unknown && n < budget {
+					t.Errorf("with %+v: got UNKNOWN after %d (%s)", opts, n, stat)
+				}
+				if result != Unknown && result != want {
+					t.Errorf("with %+v: got %s; want %s", opts, result, want)
+				}
+			}
+		})
+	}
+}
+
+func TestTrace(t *testing.T) {
+	problem := [][]int{{1, 2}, {-1, 2}, {1, -2}, {-1, -2, 3}, {-3, -2}}
+	var b strings.Builder
+	opts := &Options{Polarity: PolarityFalse, NoPreprocess: true, Trace: &b}
+	if _, _, ok := SolveWithOptions(problem, opts); ok {
+		t.Fatal("got SAT")
+	}
+	want := `
+decide lit=-1 level=1
+propagate lit=2 level=1 reason=[2,1]
+conflict level=1 clause=[-2,1]
+learn clause=[1] lbd=1
+backjump from=1 to=0
+propagate lit=1 level=0 reason=[1]
+propagate lit=2 level=0 reason=[2,-1]
+propagate lit=3 level=0 reason=[3,-2,-1]
+conflict level=0 clause=[-3,-2]
+result value=UNSAT
+`
+	if got := b.String(); got != strings.TrimPrefix(want, "\n") {
+		t.Fatalf("got trace:\n%s\nwant:\n%s", got, want)
+	}
+}
+
 func TestRandomized(t *testing.T) {
 	for _, tt := range []struct {
 		numVars    int
@@ -60,7 +260,7 @@ func BenchmarkFixtures(b *testing.B) {
 	for _, bb := range loadFixtures(b, true) {
 		b.Run(bb.name, func(b *testing.B) {
 			for i := 0; i < b.N; i++ {
-				sv := newSolver(bb.problem)
+				sv := newSolver(bb.problem, nil)
 				sv.solve()
 				b.ReportMetric(float64(sv.numDecisions), "decisions/op")
 				b.ReportMetric(float64(sv.numImplications), "implications/op")
@@ -81,11 +281,13 @@ func loadFixtures(tb testing.TB, onlyBench bool) []fixtureTest {
 		tb.Fatal(err)
 	}
 	if !onlyBench {
-		nonBench, err := filepath.Glob("testdata/*.cnf")
-		if err != nil {
-			tb.Fatal(err)
+		for _, pat := range []string{"testdata/*.cnf", "testdata/slow/*.cnf"} {
+			nonBench, err := filepath.Glob(pat)
+			if err != nil {
+				tb.Fatal(err)
+			}
+			filenames = append(filenames, nonBench...)
 		}
-		filenames = append(filenames, nonBench...)
 	}
 	var tests []fixtureTest
 	for _, filename := range filenames {
@@ -111,8 +313,8 @@ func loadFixtures(tb testing.TB, onlyBench bool) []fixtureTest {
 	return tests
 }
 
-func testFixtureSat(t *testing.T, problem [][]int) {
-	soln, _, ok := Solve(problem)
+func testFixtureSat(t *testing.T, problem [][]int, opts *Options) {
+	soln, _, ok := SolveWithOptions(problem, opts)
 	if !ok {
 		t.Fatalf("got UNSAT; want SAT")
 	}
@@ -144,11 +346,21 @@ clauseLoop:
 	return true
 }
 
-func testFixtureUnsat(t *testing.T, problem [][]int) {
-	soln, _, ok := Solve(problem)
+func testFixtureUnsat(t *testing.T, problem [][]int, opts *Options) {
+	// Verify the UNSAT result with a DRAT proof.
+	var o Options
+	if opts != nil {
+		o = *opts
+	}
+	var drat bytes.Buffer
+	o.Proof = &drat
+	soln, _, ok := SolveWithOptions(problem, &o)
 	if ok {
 		t.Fatalf("got SAT with assignment %v; expected UNSAT", soln)
 	}
+	if err := proof.CheckDRAT(problem, &drat); err != nil {
+		t.Fatalf("bad DRAT proof: %s", err)
+	}
 }
 
 func makeRandomSat(seed int64, numVars, numClauses int) [][]int {
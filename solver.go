@@ -0,0 +1,195 @@
+package saturday
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// A Solver is an incremental SAT solver. Clauses are added using AddClause and
+// Solve may be called any number of times, with more clauses added in between
+// calls. The Solver keeps its learned clauses and heuristic state (such as var
+// activities and saved phases) from one call to the next, so solving a series
+// of closely related problems with a single Solver is much faster than calling
+// the package-level Solve function for each one.
+//
+// Unlike Solve, a Solver doesn't require its variables to form a contiguous
+// set; any nonzero integers may be used.
+//
+// A Solver must not be used concurrently by multiple goroutines.
+type Solver struct {
+	sv  *solver
+	err error // first error from AddClause
+
+	model  []assnVal // indexed by internal var; nil if there is no model
+	failed []int
+}
+
+// NewSolver creates a Solver with no clauses that is configured using opts.
+func NewSolver(opts *Options) *Solver {
+	sv := new(solver)
+	sv.init(opts)
+	return &Solver{sv: sv}
+}
+
+// AddClause adds a clause to the problem. Each literal is a nonzero integer
+// and negative integers indicate negated variables.
+//
+// If a literal is zero, the clause is ignored and the error is reported by
+// the next call to Solve.
+func (s *Solver) AddClause(lits ...int) {
+	for _, v := range lits {
+		if v == 0 {
+			if s.err == nil {
+				s.err = fmt.Errorf("zero literal in clause %v", lits)
+			}
+			return
+		}
+	}
+	s.sv.backtrack(0)
+	s.sv.addClause(lits, 0)
+}
+
+// ErrInterrupted is returned by Solver methods when the search gives up
+// before finding an answer because a budget (see Options.MaxConflicts and
+// Options.MaxDecisions) ran out.
+var ErrInterrupted = errors.New("search interrupted")
+
+// Solve determines whether the clauses added so far are satisfiable. If they
+// are, the satisfying assignment may be inspected using Value.
+//
+// Solve returns a non-nil error if any invalid clauses were passed to
+// AddClause, if there was an error writing the proof (see Options.Proof), or
+// if the search was interrupted (in which case the error is ErrInterrupted).
+func (s *Solver) Solve() (bool, error) {
+	return s.SolveAssuming(nil)
+}
+
+// SolveAssuming is like Solve but only looks for satisfying assignments in
+// which all of the given literals (the assumptions) are true. The assumptions
+// only apply to this call; unlike unit clauses, they aren't kept.
+//
+// If SolveAssuming returns false, FailedAssumptions gives a subset of the
+// assumptions that is sufficient to make the clauses unsatisfiable.
+func (s *Solver) SolveAssuming(assumptions []int) (bool, error) {
+	if s.err != nil {
+		return false, s.err
+	}
+	s.model = s.model[:0]
+	s.failed = s.failed[:0]
+	sv := s.sv
+	sv.backtrack(0)
+	sv.assumptions = sv.assumptions[:0]
+	for _, v := range assumptions {
+		if v == 0 {
+			return false, fmt.Errorf("zero literal in assumptions %v", assumptions)
+		}
+		neg := v < 0
+		if neg {
+			v = -v
+		}
+		i, ok := sv.varIndex[v]
+		if !ok {
+			i = sv.newVar(v)
+		}
+		lit := literal(i) << 1
+		if neg {
+			lit |= 1
+		}
+		sv.assumptions = append(sv.assumptions, lit)
+	}
+	if sv.polarity == PolarityJeroslowWang {
+		sv.initJeroslowWang()
+	}
+	result := sv.solve()
+	ok := result == Satisfiable
+	switch result {
+	case Satisfiable:
+		s.model = append(s.model, sv.assignments...)
+	case Unknown:
+		s.model = nil
+	case Unsatisfiable:
+		s.model = nil
+		failed := make(map[int]struct{})
+		for _, lit := range sv.failed {
+			failed[sv.origLit(lit)] = struct{}{}
+		}
+		for _, v := range assumptions {
+			if _, ok := failed[v]; ok {
+				s.failed = append(s.failed, v)
+				delete(failed, v) // in case of duplicate assumptions
+			}
+		}
+	}
+	sv.backtrack(0)
+	if sv.proof != nil {
+		if err := sv.proof.flush(); err != nil {
+			return ok, err
+		}
+	}
+	if result == Unknown {
+		return false, ErrInterrupted
+	}
+	return ok, nil
+}
+
+// FailedAssumptions returns the assumptions passed to the most recent call to
+// SolveAssuming (in the same order) that were used to prove that the clauses
+// are unsatisfiable under the assumptions. (This is similar to the failed
+// function in the IPASIR incremental SAT solver interface.)
+//
+// If the clauses are unsatisfiable without any assumptions, or if the most
+// recent call to SolveAssuming found a satisfying assignment,
+// FailedAssumptions returns nil.
+func (s *Solver) FailedAssumptions() []int {
+	return append([]int(nil), s.failed...)
+}
+
+// Stats returns the stats accumulated over all the calls to Solve so far.
+func (s *Solver) Stats() Stats {
+	return s.sv.stats()
+}
+
+// Value returns the value of variable v in the satisfying assignment found by
+// the most recent call to Solve: v if the variable is true and -v if it is
+// false. If the most recent call to Solve didn't find a satisfying
+// assignment, or if v doesn't appear in any clause, Value returns 0.
+func (s *Solver) Value(v int) int {
+	if v < 0 {
+		v = -v
+	}
+	i, ok := s.sv.varIndex[v]
+	if !ok || i >= len(s.model) {
+		return 0
+	}
+	switch s.model[i] {
+	case assnTrue:
+		return v
+	case assnFalse:
+		return -v
+	}
+	return 0
+}
+
+// Model returns the satisfying assignment found by the most recent call to
+// Solve, in the same form as the assignment returned by the Solve function
+// (but covering only the variables that appear in the clauses).
+// It returns nil if the most recent call to Solve didn't find a satisfying
+// assignment.
+func (s *Solver) Model() []int {
+	if s.model == nil {
+		return nil
+	}
+	soln := make([]int, 0, len(s.model))
+	for i, v := range s.sv.origVars {
+		if i >= len(s.model) {
+			break
+		}
+		if s.model[i] == assnFalse {
+			v = -v
+		}
+		soln = append(soln, v)
+	}
+	sort.Slice(soln, func(i, j int) bool { return abs(soln[i]) < abs(soln[j]) })
+	return soln
+}
@@ -0,0 +1,282 @@
+package saturday
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSolverIncremental(t *testing.T) {
+	for _, tt := range loadFixtures(t, false) {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewSolver(nil)
+			const chunk = 25
+			for i := 0; i < len(tt.problem); i += chunk {
+				end := i + chunk
+				if end > len(tt.problem) {
+					end = len(tt.problem)
+				}
+				for _, cls := range tt.problem[i:end] {
+					s.AddClause(cls...)
+				}
+				ok, err := s.Solve()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !ok {
+					if tt.sat {
+						t.Fatalf("got UNSAT after %d clauses; want SAT", end)
+					}
+					if end < len(tt.problem) {
+						// Check that the rest of the
+						// clauses don't confuse it.
+						continue
+					}
+					return
+				}
+				if !solutionIsValid(tt.problem[:end], s.Model()) {
+					t.Fatalf("got invalid assignment after %d clauses", end)
+				}
+			}
+			if !tt.sat {
+				t.Fatal("got SAT; want UNSAT")
+			}
+		})
+	}
+}
+
+func TestSolverRandom(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	for i := 0; i < 100; i++ {
+		const numVars = 20
+		s := NewSolver(nil)
+		var problem [][]int
+		for j := 0; j < 10; j++ {
+			// Add a batch of random 3-SAT clauses. With 100 clauses
+			// in the end, about half of these are unsatisfiable.
+			for k := 0; k < 10; k++ {
+				cls := make([]int, 3)
+				for l := range cls {
+					cls[l] = rng.Intn(numVars) + 1
+					if rng.Intn(2) == 0 {
+						cls[l] = -cls[l]
+					}
+				}
+				problem = append(problem, cls)
+				s.AddClause(cls...)
+			}
+			_, _, want := Solve(problem)
+			got, err := s.Solve()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != want {
+				t.Fatalf("[%d] Solver.Solve gave %t; Solve gave %t for %v", i, got, want, problem)
+			}
+			if got && !solutionIsValid(problem, s.Model()) {
+				t.Fatalf("[%d] got invalid assignment", i)
+			}
+		}
+	}
+}
+
+func TestSolverValue(t *testing.T) {
+	s := NewSolver(nil)
+	s.AddClause(-10, 20)
+	s.AddClause(10)
+	if v := s.Value(10); v != 0 {
+		t.Fatalf("before Solve: Value(10) = %d; want 0", v)
+	}
+	ok, err := s.Solve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Fatal("got UNSAT")
+	}
+	for _, tt := range []struct {
+		v    int
+		want int
+	}{
+		{10, 10},
+		{-10, 10},
+		{20, 20},
+		{30, 0},
+	} {
+		if got := s.Value(tt.v); got != tt.want {
+			t.Errorf("Value(%d) = %d; want %d", tt.v, got, tt.want)
+		}
+	}
+	s.AddClause(-20)
+	if ok, _ := s.Solve(); ok {
+		t.Fatal("got SAT after adding contradiction")
+	}
+	if v := s.Value(10); v != 0 {
+		t.Fatalf("after UNSAT: Value(10) = %d; want 0", v)
+	}
+}
+
+func TestSolverZeroLiteral(t *testing.T) {
+	s := NewSolver(nil)
+	s.AddClause(1, 0, 2)
+	if _, err := s.Solve(); err == nil {
+		t.Fatal("got nil error for clause with a zero literal")
+	}
+}
+
+func TestSolverBudget(t *testing.T) {
+	for _, tt := range loadFixtures(t, false) {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewSolver(&Options{MaxConflicts: 10})
+			for _, cls := range tt.problem {
+				s.AddClause(cls...)
+			}
+			// The learned clauses carry over from one call to
+			// the next, so the solver eventually finishes.
+			for {
+				sat, err := s.Solve()
+				if err == ErrInterrupted {
+					continue
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				if sat != tt.sat {
+					t.Fatalf("got sat=%t; want %t", sat, tt.sat)
+				}
+				break
+			}
+		})
+	}
+}
+
+func ExampleSolver() {
+	s := NewSolver(nil)
+	s.AddClause(-1, 2)
+	s.AddClause(-2, 3)
+	ok, _ := s.Solve()
+	fmt.Println(ok, s.Model())
+
+	// Add more clauses and try again.
+	s.AddClause(1)
+	s.AddClause(-3)
+	ok, _ = s.Solve()
+	fmt.Println(ok)
+	// Output:
+	// true [1 2 3]
+	// false
+}
+
+func TestSolveAssuming(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	var numFailed int
+	for i := 0; i < 200; i++ {
+		const numVars = 20
+		problem := makeRandomSat(int64(i), numVars, 40)
+		s := NewSolver(nil)
+		for _, cls := range problem {
+			s.AddClause(cls...)
+		}
+		for j := 0; j < 5; j++ {
+			var assumptions []int
+			for k := rng.Intn(8); k > 0; k-- {
+				v := rng.Intn(numVars) + 1
+				if rng.Intn(2) == 0 {
+					v = -v
+				}
+				assumptions = append(assumptions, v)
+			}
+			withUnits := append([][]int(nil), problem...)
+			for _, v := range assumptions {
+				withUnits = append(withUnits, []int{v})
+			}
+			_, _, want := Solve(withUnits)
+			got, err := s.SolveAssuming(assumptions)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != want {
+				t.Fatalf("[%d] SolveAssuming(%v) gave %t; want %t", i, assumptions, got, want)
+			}
+			if got {
+				if !solutionIsValid(withUnits, s.Model()) {
+					t.Fatalf("[%d] got invalid assignment", i)
+				}
+				continue
+			}
+			numFailed++
+			failed := s.FailedAssumptions()
+			if len(failed) == 0 {
+				t.Fatalf("[%d] no failed assumptions for %v", i, assumptions)
+			}
+			withFailed := append([][]int(nil), problem...)
+			for _, v := range failed {
+				if !intsContain(assumptions, v) {
+					t.Fatalf("[%d] failed assumption %d is not in %v", i, v, assumptions)
+				}
+				withFailed = append(withFailed, []int{v})
+			}
+			if _, _, ok := Solve(withFailed); ok {
+				t.Fatalf("[%d] failed assumptions %v (of %v) are satisfiable", i, failed, assumptions)
+			}
+		}
+		// The assumptions shouldn't stick around.
+		if ok, _ := s.Solve(); !ok {
+			t.Fatalf("[%d] got UNSAT without assumptions", i)
+		}
+	}
+	if numFailed == 0 {
+		t.Fatal("no assumptions failed")
+	}
+}
+
+func TestSolveAssumingDuplicates(t *testing.T) {
+	// 1 is implied by 3, so several of these assumptions are already
+	// true by the time the solver reaches them.
+	s := NewSolver(nil)
+	s.AddClause(1, 3)
+	s.AddClause(-2, 3)
+	s.AddClause(-2, -3)
+	s.AddClause(-3, 1)
+	for _, tt := range []struct {
+		assumptions []int
+		sat         bool
+		failed      []int
+	}{
+		{[]int{1, 1, 1, 1}, true, nil},
+		{[]int{3, 1, 3, 1, 1}, true, nil},
+		{[]int{1, 1, 1, 1, 2}, false, []int{2}},
+		{[]int{3, 3, 1, 1, 3, 2}, false, []int{2}},
+		{[]int{-2, -2, 3, 3, 1, -2}, true, nil},
+	} {
+		got, err := s.SolveAssuming(tt.assumptions)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.sat {
+			t.Fatalf("SolveAssuming(%v) gave %t; want %t", tt.assumptions, got, tt.sat)
+		}
+		if got {
+			for _, v := range tt.assumptions {
+				if s.Value(v) != v {
+					t.Errorf("SolveAssuming(%v): model %v doesn't satisfy %d", tt.assumptions, s.Model(), v)
+				}
+			}
+			continue
+		}
+		if diff := cmp.Diff(s.FailedAssumptions(), tt.failed); diff != "" {
+			t.Errorf("SolveAssuming(%v): failed assumptions (-got, +want):\n%s", tt.assumptions, diff)
+		}
+	}
+}
+
+func ExampleSolver_SolveAssuming() {
+	s := NewSolver(nil)
+	s.AddClause(-1, 2)
+	s.AddClause(-2, 3)
+	ok, _ := s.SolveAssuming([]int{4, 1, -3})
+	fmt.Println(ok, s.FailedAssumptions())
+	// Output: false [1 -3]
+}
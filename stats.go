@@ -0,0 +1,83 @@
+package saturday
+
+import "time"
+
+// Stats gives information about the work done by the solver. The stats are
+// purely informational; new fields may be added at any time.
+type Stats struct {
+	// SolvedBySimplification is true if the problem was found to be
+	// satisfiable or unsatisfiable during the initial simplification,
+	// before the search started.
+	SolvedBySimplification bool
+	EliminatedVars         int64 // vars removed by preprocessing
+	SubsumedClauses        int64 // clauses removed because they were subsumed by others
+	StrengthenedLits       int64 // literals removed by self-subsuming resolution
+	SubstitutedVars        int64 // vars replaced by equivalent literals
+	ProbedUnits            int64 // vars assigned by failed literal probing
+	PureLiterals           int64 // vars removed because one of their literals was pure
+	BlockedClauses         int64 // clauses removed by blocked clause elimination
+
+	Decisions    int64
+	Implications int64
+	Conflicts    int64
+	Restarts     int64
+
+	LearnedClauses int64
+	DeletedClauses int64 // learned clauses removed from the clause database
+	MinimizedLits  int64 // literals removed from learned clauses by minimization
+	DBReductions   int64 // number of times the learned clause database was reduced
+	ElapsedTime    time.Duration
+}
+
+// KeptClauses is the number of learned clauses that haven't been deleted.
+func (s Stats) KeptClauses() int64 {
+	return s.LearnedClauses - s.DeletedClauses
+}
+
+// stats returns a snapshot of the current stats.
+func (sv *solver) stats() Stats {
+	elapsed := sv.elapsed
+	if !sv.start.IsZero() {
+		elapsed += time.Since(sv.start)
+	}
+	return Stats{
+		SolvedBySimplification: sv.simpleSat != unassigned,
+		EliminatedVars:         sv.numEliminated,
+		SubsumedClauses:        sv.numSubsumed,
+		StrengthenedLits:       sv.numStrengthened,
+		SubstitutedVars:        sv.numSubstituted,
+		ProbedUnits:            sv.numProbed,
+		PureLiterals:           sv.numPure,
+		BlockedClauses:         sv.numBlocked,
+		Decisions:              sv.numDecisions,
+		Implications:           sv.numImplications,
+		Conflicts:              sv.numConflicts,
+		Restarts:               sv.numRestarts,
+		LearnedClauses:         sv.numLearned,
+		DeletedClauses:         sv.numDeleted,
+		MinimizedLits:          sv.numMinimized,
+		DBReductions:           sv.numReductions,
+		ElapsedTime:            elapsed,
+	}
+}
+
+const (
+	// The solver checks whether it's time to report progress after every
+	// progressCheckConflicts conflicts.
+	progressCheckConflicts  = 256
+	defaultProgressInterval = time.Second
+)
+
+// maybeReportProgress calls the progress callback (if any) if enough time
+// has passed since the last time it was called.
+func (sv *solver) maybeReportProgress() {
+	if sv.progress == nil || sv.numConflicts%progressCheckConflicts != 0 {
+		return
+	}
+	now := time.Now()
+	if now.Before(sv.nextProgress) {
+		return
+	}
+	sv.nextProgress = now.Add(sv.progressInterval)
+	sv.progress(sv.stats())
+}
@@ -0,0 +1,75 @@
+package saturday
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestProgress(t *testing.T) {
+	// A random 3-SAT problem near the satisfiability threshold takes
+	// plenty of conflicts to solve.
+	rng := rand.New(rand.NewSource(0))
+	const numVars = 150
+	var problem [][]int
+	for i := 0; i < numVars*426/100; i++ {
+		var cls []int
+		for _, v := range rng.Perm(numVars)[:3] {
+			v++
+			if rng.Intn(2) == 0 {
+				v = -v
+			}
+			cls = append(cls, v)
+		}
+		problem = append(problem, cls)
+	}
+
+	var snapshots []Stats
+	opts := &Options{
+		Progress: func(stats Stats) {
+			snapshots = append(snapshots, stats)
+		},
+		ProgressInterval: time.Nanosecond,
+	}
+	_, stats, sat := SolveWithOptions(problem, opts)
+	if stats.Conflicts < progressCheckConflicts {
+		t.Fatalf("only got %d conflicts", stats.Conflicts)
+	}
+	reported := stats.Conflicts
+	if !sat {
+		// The final conflict ends the search without a progress call.
+		reported--
+	}
+	if want := reported / progressCheckConflicts; int64(len(snapshots)) != want {
+		t.Errorf("got %d progress calls; want %d", len(snapshots), want)
+	}
+	prev := Stats{}
+	for _, snap := range append(snapshots, stats) {
+		if snap.Decisions < prev.Decisions ||
+			snap.Conflicts < prev.Conflicts ||
+			snap.LearnedClauses < prev.LearnedClauses ||
+			snap.ElapsedTime < prev.ElapsedTime {
+			t.Fatalf("stats went backwards: %+v, then %+v", prev, snap)
+		}
+		prev = snap
+	}
+}
+
+func TestSolverStats(t *testing.T) {
+	s := NewSolver(nil)
+	s.AddClause(1, 2)
+	s.AddClause(-1, 2)
+	s.AddClause(1, -2)
+	if _, err := s.Solve(); err != nil {
+		t.Fatal(err)
+	}
+	first := s.Stats()
+	s.AddClause(-1, -2)
+	if _, err := s.Solve(); err != nil {
+		t.Fatal(err)
+	}
+	stats := s.Stats()
+	if stats.Decisions < first.Decisions || stats.Conflicts == 0 || stats.ElapsedTime < first.ElapsedTime {
+		t.Fatalf("stats didn't accumulate: %+v, then %+v", first, stats)
+	}
+}
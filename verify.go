@@ -0,0 +1,48 @@
+package saturday
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Verify checks that assignment (in the form returned by Solve) satisfies
+// every clause of problem. If it does not, Verify returns an error that
+// reports the first clause that isn't satisfied. Variables that don't appear
+// in assignment are treated as unassigned, so a clause containing only such
+// variables is not satisfied.
+//
+// Verify is independent of the solver and may be used to check assignments
+// found by other means.
+func Verify(problem [][]int, assignment []int) error {
+	// vals[v] is 1 if v is true, -1 if v is false, and 0 if unassigned.
+	// (The assignment may come from an untrusted source, so this is a map
+	// rather than a slice indexed by var.)
+	vals := make(map[int]int8, len(assignment))
+	for _, lit := range assignment {
+		if lit == 0 {
+			return errors.New("zero literal in assignment")
+		}
+		v, val := lit, int8(1)
+		if v < 0 {
+			v, val = -v, -1
+		}
+		if vals[v] == -val {
+			return fmt.Errorf("assignment contains both %d and %d", v, -v)
+		}
+		vals[v] = val
+	}
+clauseLoop:
+	for i, cls := range problem {
+		for _, lit := range cls {
+			v, val := lit, int8(1)
+			if v < 0 {
+				v, val = -v, -1
+			}
+			if vals[v] == val {
+				continue clauseLoop
+			}
+		}
+		return fmt.Errorf("clause %d %v is not satisfied", i, cls)
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package saturday
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestVerify(t *testing.T) {
+	problem := [][]int{{-1, 2}, {-2, 3}, {1, -3, 2}}
+	for _, tt := range []struct {
+		assignment []int
+		wantErr    string // substring; empty means valid
+	}{
+		{[]int{-1, 2, 3}, ""},
+		{[]int{1, 2, 3}, ""},
+		{[]int{3, 2, -1}, ""},
+		{[]int{1, -2, 3}, "clause 0 [-1 2]"},
+		{[]int{-1, 2, -3}, "clause 1 [-2 3]"},
+		{[]int{-1, -2, 3}, "clause 2 [1 -3 2]"},
+		{[]int{-1, 3}, "clause 2 [1 -3 2]"}, // 2 is unassigned
+		{nil, "clause 0"},
+		{[]int{-1, 2, 3, -2}, "both 2 and -2"},
+		{[]int{-1, 0, 3}, "zero literal"},
+		{[]int{-1, 2, 3, 1 << 40}, ""},
+		{[]int{1 << 40, -(1 << 40)}, "both 1099511627776 and -1099511627776"},
+	} {
+		err := Verify(problem, tt.assignment)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("Verify(%v): got error %q; want nil", tt.assignment, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("Verify(%v): got error %v; want error containing %q",
+				tt.assignment, err, tt.wantErr)
+		}
+	}
+}